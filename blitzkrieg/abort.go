@@ -0,0 +1,128 @@
+package blitzkrieg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An abortCondition stops the run early when the target collapses. It is
+// either a threshold style breach that has to hold on every interval for
+// some time:
+//
+//	error_rate>50% for 10s    p99>5s for 30s    rps<10 for 1m
+//
+// or a count of transport errors in a row, of any kind or of one category:
+//
+//	consecutive_errors>=500    consecutive_refused>=100
+//
+// Without "for" a single interval in breach is enough.
+type abortCondition struct {
+	expr        string
+	breach      *threshold    // nil for consecutive conditions
+	forDuration time.Duration // how long the breach has to last
+	since       time.Duration // start of the current breach, -1 when none
+
+	consecutive bool
+	class       string // error category counted in a row, empty for any
+	limit       int64
+	count       int64 // errors in a row so far
+}
+
+var (
+	abortFor         = regexp.MustCompile(`^(.*\S)\s+for\s+(\S+)\s*$`)
+	abortConsecutive = regexp.MustCompile(`^\s*consecutive_([a-z]+)\s*(>=|>)\s*(\d+)\s*$`)
+)
+
+// parseAbort parses an abort condition
+func parseAbort(expr string) (*abortCondition, error) {
+	a := &abortCondition{expr: strings.TrimSpace(expr), since: -1}
+	if m := abortConsecutive.FindStringSubmatch(expr); m != nil {
+		a.consecutive = true
+		a.limit, _ = strconv.ParseInt(m[3], 10, 64)
+		if m[2] == ">" {
+			a.limit++
+		}
+		switch m[1] {
+		case "errors":
+		case "refused":
+			a.class = errRefused
+		case "resets":
+			a.class = errReset
+		case "timeouts":
+			a.class = errConnectTimeout
+		default:
+			return nil, fmt.Errorf("unknown error kind in abort condition: %s", expr)
+		}
+		return a, nil
+	}
+	if m := abortFor.FindStringSubmatch(expr); m != nil {
+		d, err := time.ParseDuration(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration in abort condition: %s", expr)
+		}
+		expr, a.forDuration = m[1], d
+	}
+	breach, err := parseThreshold(expr)
+	if err != nil {
+		return nil, err
+	}
+	if breach.name != "" {
+		return nil, fmt.Errorf("abort conditions apply to the whole test: %s", a.expr)
+	}
+	a.breach = breach
+	return a, nil
+}
+
+// checkResult counts result against the consecutive conditions and returns
+// the reason to abort, if any
+func (s *stats) checkResult(result *blitzResult) string {
+	for _, a := range s.aborts {
+		if !a.consecutive {
+			continue
+		}
+		if result.err != nil && (a.class == "" || a.class == result.errClass) {
+			a.count++
+		} else {
+			a.count = 0
+		}
+		if a.count == a.limit {
+			return fmt.Sprintf("%s: %d errors in a row", a.expr, a.count)
+		}
+	}
+	return ""
+}
+
+// checkInterval evaluates the breach conditions on the interval bucket b,
+// which ends end into the test, and returns the reason to abort, if any
+func (s *stats) checkInterval(b *bucket, start time.Duration, end time.Duration) string {
+	for _, a := range s.aborts {
+		if a.consecutive {
+			continue
+		}
+		value := a.breach.measure(b, (end - start).Seconds())
+		if !a.breach.holds(value) {
+			a.since = -1
+			continue
+		}
+		if a.since < 0 {
+			a.since = start
+		}
+		if end-a.since >= a.forDuration {
+			return fmt.Sprintf("%s: %s since %3.2fs", a.expr, a.breach.format(value), a.since.Seconds())
+		}
+	}
+	return ""
+}
+
+// stop aborts the run for reason, if there is one. The report needs the
+// stats lock held by the caller, so the run is finished from a goroutine.
+func (s *stats) stop(reason string) {
+	if reason == "" || s.aborting || s.abort == nil {
+		return
+	}
+	s.aborting = true
+	go s.abort("abort condition met: " + reason)
+}
@@ -0,0 +1,112 @@
+package blitzkrieg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+var (
+	// sourceIPs are the local addresses of -bind, new connections go out
+	// from them in turn
+	sourceIPs []net.IP
+	// sourcePorts is the range of local ports of -ports, 0 to 0 to leave
+	// the ports to the system
+	sourcePorts [2]int
+	// nextSource and nextPort count the source addresses and ports handed
+	// out, for the rotation
+	nextSource, nextPort uint64
+)
+
+// maxPortTries is the number of ports of the range a new connection tries
+// before it reports the range exhausted
+const maxPortTries = 16
+
+// parseBind sets the source addresses and ports from -bind, a comma
+// separated list of local IP addresses, and -ports, a lo-hi range
+func parseBind(addrs string, ports string) error {
+	if addrs != "" {
+		for _, addr := range strings.Split(addrs, ",") {
+			ip := net.ParseIP(strings.Trim(strings.TrimSpace(addr), "[]"))
+			if ip == nil {
+				return fmt.Errorf("%s is not an IP address", addr)
+			}
+			sourceIPs = append(sourceIPs, ip)
+		}
+	}
+	if ports != "" {
+		lo, hi, ok := strings.Cut(ports, "-")
+		if !ok {
+			hi = lo
+		}
+		var err error
+		if sourcePorts[0], err = strconv.Atoi(strings.TrimSpace(lo)); err == nil {
+			sourcePorts[1], err = strconv.Atoi(strings.TrimSpace(hi))
+		}
+		if err != nil || sourcePorts[0] < 1 || sourcePorts[1] > 65535 || sourcePorts[0] > sourcePorts[1] {
+			return fmt.Errorf("%s: port ranges are lo-hi, from 1 to 65535", ports)
+		}
+	}
+	return nil
+}
+
+// bindNext calls bind with the next source address, nil without -bind, and
+// the next port of the range, 0 without -ports. While the port is taken it
+// tries the following ones, up to maxPortTries.
+func bindNext(bind func(ip net.IP, port int) error) error {
+	var ip net.IP
+	if len(sourceIPs) > 0 {
+		ip = sourceIPs[(atomic.AddUint64(&nextSource, 1)-1)%uint64(len(sourceIPs))]
+	}
+	if sourcePorts[0] == 0 {
+		return bind(ip, 0)
+	}
+	size := sourcePorts[1] - sourcePorts[0] + 1
+	var err error
+	for i := 0; i < maxPortTries && i < size; i++ {
+		port := sourcePorts[0] + int((atomic.AddUint64(&nextPort, 1)-1)%uint64(size))
+		if err = bind(ip, port); !isPortExhausted(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isPortExhausted tells whether err is the failure to get a local address
+// and port for a connection: every ephemeral port to the destination, or
+// every port of the range, is in use
+func isPortExhausted(err error) bool {
+	return errors.Is(err, syscall.EADDRNOTAVAIL) || errors.Is(err, syscall.EADDRINUSE)
+}
+
+// dialFrom dials address with dialer from the next source address and port
+func dialFrom(ctx context.Context, dialer *net.Dialer, network string, address string) (net.Conn, error) {
+	if len(sourceIPs) == 0 && sourcePorts[0] == 0 {
+		return dialer.DialContext(ctx, network, address)
+	}
+	var conn net.Conn
+	err := bindNext(func(ip net.IP, port int) error {
+		bound := *dialer
+		bound.LocalAddr = &net.TCPAddr{IP: ip, Port: port}
+		var err error
+		conn, err = bound.DialContext(ctx, network, address)
+		return err
+	})
+	return conn, err
+}
+
+// listenFrom opens a UDP socket on the next source address and port
+func listenFrom() (*net.UDPConn, error) {
+	var udp *net.UDPConn
+	err := bindNext(func(ip net.IP, port int) error {
+		var err error
+		udp, err = net.ListenUDP("udp", &net.UDPAddr{IP: ip, Port: port})
+		return err
+	})
+	return udp, err
+}
@@ -1,15 +1,17 @@
 package blitzkrieg
 
 import (
+	"bufio"
 	"crypto/tls"
 	"fmt"
 	"github.com/rakyll/pb"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"os/signal"
+	"regexp"
 	"sync"
 	"syscall"
 	"time"
@@ -17,51 +19,74 @@ import (
 
 // A Blitz contains all the vars to perform the load test
 type Blitz struct {
-	requests       []*blitzRequest      // generated from URL/URLs file
-	count          int                  //Number of requests
-	clients        int                  //The number of concurrent clients to run
-	duration       int                  // Duration to run the test
-	keepAlive      bool                 //Whether to set KeepAlive ON or NOT
-	gzip           bool                 //Whether to enable gzip or not
-	connectTimeout int                  //Connect timeout in ms
-	readTimeout    int                  //Read timeout in ms
-	writeTimeout   int                  //Write timeout in ms
-	rate           int                  // Rate limit.
-	header         http.Header          // Http Headers
-	startTime      time.Time            // Start time
-	bar            *pb.ProgressBar      // Progress bar
-	jobs           chan *blitzRequest   //Jobs channel
-	results        chan *[]*blitzResult //Results Channel holds array of blitzResult (size blitz.clients)
-}
-
-type BlitzConn struct {
-	net.Conn
-	readTimeout  time.Duration
-	writeTimeout time.Duration
-}
-
-func (blitzConn *BlitzConn) Read(b []byte) (n int, err error) {
-	len, err := blitzConn.Conn.Read(b)
-	if err == nil {
-		blitzConn.Conn.SetReadDeadline(time.Now().Add(blitzConn.readTimeout))
-	}
-	return len, err
-}
-
-func (blitzConn *BlitzConn) Write(b []byte) (n int, err error) {
-	len, err := blitzConn.Conn.Write(b)
-	if err == nil {
-		blitzConn.Conn.SetWriteDeadline(time.Now().Add(blitzConn.writeTimeout))
-	}
-	return len, err
+	requests       []*blitzRequest    // generated from URL/URLs file
+	count          int                //Number of requests
+	clients        int                //The number of concurrent clients to run
+	duration       int                // Duration to run the test
+	keepAlive      bool               //Whether to set KeepAlive ON or NOT
+	gzip           bool               //Whether to enable gzip or not
+	connectTimeout int                //Connect timeout in ms
+	readTimeout    int                //Read timeout in ms
+	writeTimeout   int                //Write timeout in ms
+	rate           int                // Rate limit.
+	header         http.Header        // Http Headers
+	startTime      time.Time          // Start time
+	bar            *pb.ProgressBar    // Progress bar
+	jobs           chan *blitzRequest //Jobs channel
+	results        chan *blitzResult  //Results channel, drained by collect()
+	collected      chan bool          //Closed once collect() has drained the results channel
+	interval       time.Duration      //Aggregation interval
+	rawLogPath     string             //File to log every result to, if any
+	stats          *stats             //Results aggregated on the fly
+	conns          *connStats         //Connection lifecycle counters
+	thresholds     []*threshold       //Pass/fail conditions checked at the end
+	aborts         []*abortCondition  //Conditions that stop the run early
+	samples        *sampler           //Failed requests kept for debugging, if any
+	proto          string             //Protocol spoken to the target
+	connsPerClient int                //Connections each client keeps
+	streams        int                //Requests each client keeps in flight
+	zeroRTT        bool               //Send GET and HEAD requests as 0-RTT data with h3
+	quic           *quicStats         //QUIC connection stats, with h3
+	tls            *tlsStats          //TLS handshake stats
+	messages       []string           //WebSocket messages, sent in turn
+	expect         string             //Pattern of the WebSocket replies
+	expectRe       *regexp.Regexp     //The pattern compiled, when it has no placeholders
+	msgRate        int                //WebSocket messages per second per connection
+	ws             *wsStats           //WebSocket connection stats
+	wsClients      int64              //WebSocket clients so far, numbers them
+	grpcClients    int64              //gRPC clients so far, numbers them
+	events         string             //How streamed responses are split into events, if they are
+	pipeline       int                //HTTP/1.1 requests in flight per connection, when pipelining
+	storm          bool               //Only open connections and run their TLS handshakes
+	finishOnce     sync.Once          //Guards finish()
+	aborted        string             //Why the run was cut short, if it was
 }
 
 // Run sets up the variables and runs the load test
 func (blitz *Blitz) Run() {
 	//Results channel
-	blitz.results = make(chan *[]*blitzResult, blitz.clients)
+	blitz.results = make(chan *blitzResult, blitz.clients*5)
+	blitz.collected = make(chan bool)
+	blitz.conns = &connStats{}
+	blitz.ws = &wsStats{}
+	blitz.tls = newTLSStats()
+	if blitz.proto == protoH3 {
+		blitz.quic = newQuicStats()
+	}
+	blitz.stats = newStats(blitz.interval, blitz.conns)
+	blitz.stats.aborts = blitz.aborts
+	blitz.stats.abort = blitz.finish
+	if blitz.rawLogPath != "" {
+		rawLog, err := os.Create(blitz.rawLogPath)
+		if err != nil {
+			configError("Error creating log file:%s Error: %s", blitz.rawLogPath, err)
+		}
+		defer rawLog.Close()
+		blitz.stats.rawLog = bufio.NewWriter(rawLog)
+		fmt.Fprintln(blitz.stats.rawLog, "elapsed\tname\tstatus\tduration\tsize\terror")
+	}
 	if blitz.duration != 0 { // test to be run for blitz.duration seconds
-		setTimeout(blitz.duration)
+		blitz.setTimeout()
 		blitz.bar = newPBar(blitz.duration)
 		go blitz.showDurationPBar()
 	} else { // test to be run for blitz.count requests
@@ -82,6 +107,8 @@ func (blitz *Blitz) run() {
 		throttler = time.Tick(time.Duration(1e6/(blitz.rate)) * time.Microsecond)
 	}
 	blitz.startTime = time.Now()
+	blitz.stats.begin(blitz.startTime)
+	go blitz.collect()
 	var waitr sync.WaitGroup
 	waitr.Add(blitz.clients)
 	fmt.Printf("Preparing %d concurrent users:\n", blitz.clients)
@@ -104,71 +131,197 @@ func (blitz *Blitz) run() {
 	}
 	close(blitz.jobs)
 	waitr.Wait()
-	blitz.bar.Finish()
-	blitz.report()
+	close(blitz.results)
+	<-blitz.collected
+	blitz.finish("")
+}
+
+// finish reports the results and exits with the matching exit code. Only
+// the first call does anything, whichever of the end of the requests, the
+// end of the duration or an interrupt comes first; aborted tells why the
+// run was cut short, if it was.
+func (blitz *Blitz) finish(aborted string) {
+	blitz.finishOnce.Do(func() {
+		blitz.aborted = aborted
+		blitz.bar.Finish()
+		os.Exit(blitz.report())
+	})
 }
 
+// raider is a simulated client. It keeps up to connsPerClient connections
+// and spreads its streams over them.
 func (blitz *Blitz) raider() {
-	result := make([]*blitzResult, 0)
-	blitz.results <- &result
-	tr := &http.Transport{
-		TLSClientConfig:    &tls.Config{InsecureSkipVerify: true},
-		DisableKeepAlives:  !blitz.keepAlive,
-		DisableCompression: !blitz.gzip,
+	transports := make([]http.RoundTripper, blitz.connsPerClient)
+	grpcSlots := make([]*grpcSlot, blitz.connsPerClient)
+	for i := range transports {
+		transports[i] = blitz.transport()
+		grpcSlots[i] = &grpcSlot{}
 	}
-	tr.Dial = func(network string, address string) (net.Conn, error) {
-		conn, err := net.DialTimeout(network, address, time.Duration(blitz.connectTimeout)*time.Millisecond)
-		if err != nil {
-			return nil, err
-		}
-		conn.SetReadDeadline(time.Now().Add(time.Duration(blitz.readTimeout) * time.Millisecond))
-		conn.SetWriteDeadline(time.Now().Add(time.Duration(blitz.writeTimeout) * time.Millisecond))
-
-		bConn := &BlitzConn{Conn: conn, readTimeout: time.Duration(blitz.readTimeout) * time.Millisecond, writeTimeout: time.Duration(blitz.writeTimeout) * time.Millisecond}
-		return bConn, nil
-
+	var streams sync.WaitGroup
+	streams.Add(blitz.streams)
+	for i := 0; i < blitz.streams; i++ {
+		go func(tr http.RoundTripper, gs *grpcSlot) {
+			blitz.attack(tr, gs)
+			streams.Done()
+		}(transports[i%len(transports)], grpcSlots[i%len(grpcSlots)])
 	}
+	streams.Wait()
+}
+
+// attack sends requests from the jobs channel through tr, or the gRPC client
+// of gs for gRPC requests, one at a time
+func (blitz *Blitz) attack(tr http.RoundTripper, gs *grpcSlot) {
 	//client := &http.Client{Transport: tr}
 
+	var (
+		ws       *wsClient
+		raw      *rawClient
+		pipe     *pipeClient
+		stormCfg *tls.Config
+	)
 	for req := range blitz.jobs {
+		if blitz.storm {
+			if stormCfg == nil {
+				stormCfg = stormTLS()
+			}
+			blitz.handshake(req, stormCfg)
+			if blitz.duration == 0 {
+				blitz.bar.Increment()
+			}
+			continue
+		}
+		if blitz.isPipelined(req) {
+			if pipe == nil {
+				pipe = blitz.newPipeClient()
+			}
+			pipe.send(req)
+			if blitz.duration == 0 {
+				blitz.bar.Increment()
+			}
+			continue
+		}
+		if req.method == "RAW" {
+			if raw == nil {
+				raw = blitz.newRawClient()
+			}
+			raw.send(req)
+			if blitz.duration == 0 {
+				blitz.bar.Increment()
+			}
+			continue
+		}
+		if req.rpc != nil {
+			gs.client(blitz).call(req)
+			if blitz.duration == 0 {
+				blitz.bar.Increment()
+			}
+			continue
+		}
+		if isWebSocket(req.url) {
+			if ws == nil {
+				ws = blitz.newWSClient()
+			}
+			ws.send(req)
+			if blitz.duration == 0 {
+				blitz.bar.Increment()
+			}
+			continue
+		}
+		timer := &phaseTimer{quic: blitz.quic, serial: blitz.proto == protoHTTP1}
+		hReq := req.getHttpRequest()
+		if blitz.zeroRTT {
+			early(hReq)
+		}
+		hReq = hReq.WithContext(httptrace.WithClientTrace(hReq.Context(), timer.trace()))
 		s := time.Now()
 		//resp, err := client.Do(req.getHttpRequest())
-		resp, err := tr.RoundTrip(req.getHttpRequest())
-		code := 0
-		var size int64 = 0
+		resp, err := tr.RoundTrip(hReq)
+		code, proto := 0, ""
+		var (
+			body                       []byte
+			events                     *eventTimes
+			readErr                    error
+			size, bodySize, headerSize int64
+			success                    bool
+			failed                     []string
+		)
 		if resp != nil {
-			code = resp.StatusCode
-			if body, err := ioutil.ReadAll(resp.Body); err == nil {
-				if code >= 200 && code <= 302 {
-					size = int64(len(body))
-				}
+			code, proto = resp.StatusCode, resp.Proto
+			headerSize = responseHeaderSize(resp)
+			if blitz.events != "" {
+				body, events, readErr = readEvents(resp.Body, blitz.events, s)
+			} else {
+				body, readErr = ioutil.ReadAll(resp.Body)
 			}
 			io.Copy(ioutil.Discard, resp.Body)
 			resp.Body.Close()
 		}
-		result = append(result, &blitzResult{
+		timer.release()
+		if !blitz.keepAlive && blitz.proto != protoHTTP1 {
+			closeIdle(tr)
+		}
+		end := time.Now()
+		wireIn, wireOut := timer.wireBytes()
+		if resp != nil {
+			bodySize = int64(len(body))
+			failed = req.verify(resp, body, end.Sub(s))
+			success = (req.hasStatusCheck() || isSuccess(code)) && len(failed) == 0 && readErr == nil
+			if success {
+				size = bodySize
+			}
+		}
+		if err == nil && readErr != nil {
+			// the connection broke off the body, a drop of the emulated
+			// network among others
+			err = readErr
+		}
+		errClass := ""
+		if err != nil {
+			errClass = classifyError(err)
+		}
+		if !success && blitz.samples != nil {
+			blitz.samples.capture(req, s, end.Sub(s), resp, body, err, errClass, failed)
+		}
+		blitz.results <- &blitzResult{
+			name:          req.name,
 			statusCode:    code,
-			duration:      time.Now().Sub(s),
+			duration:      end.Sub(s),
+			phases:        timer.finish(end),
+			newConn:       timer.gotConn && !timer.reused,
+			reused:        timer.reused,
 			err:           err,
+			errClass:      errClass,
+			success:       success,
+			failedChecks:  failed,
 			contentLength: size,
+			proto:         proto,
+			streams:       timer.streams,
+			events:        events,
+			bodySize:      bodySize,
+			headerIn:      headerSize,
+			headerOut:     requestHeaderSize(hReq),
+			wireIn:        wireIn,
+			wireOut:       wireOut,
 			timestamp:     time.Now(),
-		})
+		}
 		if blitz.duration == 0 {
 			blitz.bar.Increment()
 		}
 	}
-
+	if pipe != nil {
+		pipe.close()
+	}
+	if ws != nil {
+		ws.close()
+	}
 }
 
 func (blitz *Blitz) handleInterrupts() {
 	signalChannel := make(chan os.Signal, 2) // Handle Interruptions
 	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		_ = <-signalChannel
-		blitz.jobs = nil
-		blitz.bar.Finish()
-		blitz.report()
-		os.Exit(0)
+		sig := <-signalChannel
+		blitz.finish("interrupted by " + sig.String())
 	}()
 }
 
@@ -188,16 +341,9 @@ func newPBar(size int) (bar *pb.ProgressBar) {
 	return
 }
 
-func setTimeout(duration int) {
-	timeout := make(chan bool, 1)
-
-	go func() {
-		time.Sleep(time.Duration(duration) * time.Second)
-		timeout <- true
-	}()
+func (blitz *Blitz) setTimeout() {
 	go func() {
-		<-timeout
-		//signal would be sent to signalChannel and report() would be called
-		syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+		time.Sleep(time.Duration(blitz.duration) * time.Second)
+		blitz.finish("")
 	}()
 }
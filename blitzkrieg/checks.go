@@ -0,0 +1,232 @@
+package blitzkrieg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/xeipuuv/gojsonschema"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A check is an assertion on the response to a request. Checks are written
+// as key=value (or key~regex) and the expression doubles as the check's name
+// in reports:
+//
+//	status=200,201,3xx     status code is one of the list
+//	header=Name            header is present
+//	header=Name~regex      header matches regex
+//	body=text              body contains text
+//	body~regex             body matches regex
+//	json=$.path.to[0]==v   JSON value at path equals v (JSON, or a bare string)
+//	schema=file.json       body validates against the JSON schema
+//	minsize=N, maxsize=N   decoded body size in bytes
+//	maxlatency=N           latency in ms
+//
+// The replies of WebSocket requests are checked like bodies, the status and
+// header checks only apply to HTTP responses. gRPC calls and handshake
+// storms take no checks.
+type check struct {
+	name   string
+	status bool // whether the check replaces the default 200-302 status range
+	http   bool // whether the check needs the HTTP response, not just the body
+	verify func(resp *http.Response, body []byte, duration time.Duration) bool
+}
+
+// statusExpr matches the codes of a status check: three digits, or a digit
+// followed by xx for a whole class
+var statusExpr = regexp.MustCompile(`^(?:\d{3}|\dxx)$`)
+
+// parseCheck parses a check expression
+func parseCheck(expr string) (*check, error) {
+	expr = strings.TrimSpace(expr)
+	i := strings.IndexAny(expr, "=~")
+	if i < 1 {
+		return nil, fmt.Errorf("invalid check: %s", expr)
+	}
+	key, op, value := expr[:i], expr[i], expr[i+1:]
+	c := &check{name: expr}
+	switch {
+	case key == "status" && op == '=':
+		var codes []string
+		for _, code := range strings.Split(value, ",") {
+			code = strings.TrimSpace(code)
+			if !statusExpr.MatchString(code) {
+				return nil, fmt.Errorf("invalid status in check: %s", expr)
+			}
+			codes = append(codes, code)
+		}
+		c.status, c.http = true, true
+		c.verify = func(resp *http.Response, body []byte, duration time.Duration) bool {
+			got := strconv.Itoa(resp.StatusCode)
+			for _, code := range codes {
+				if code == got || strings.HasSuffix(code, "xx") && code[0] == got[0] {
+					return true
+				}
+			}
+			return false
+		}
+	case key == "header" && op == '=':
+		name, pattern := value, ""
+		if j := strings.Index(value, "~"); j >= 0 {
+			name, pattern = value[:j], value[j+1:]
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in check: %s: %s", expr, err)
+		}
+		c.http = true
+		c.verify = func(resp *http.Response, body []byte, duration time.Duration) bool {
+			for _, v := range resp.Header.Values(name) {
+				if re.MatchString(v) {
+					return true
+				}
+			}
+			return false
+		}
+	case key == "body" && op == '=':
+		text := []byte(value)
+		c.verify = func(resp *http.Response, body []byte, duration time.Duration) bool {
+			return bytes.Contains(body, text)
+		}
+	case key == "body" && op == '~':
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in check: %s: %s", expr, err)
+		}
+		c.verify = func(resp *http.Response, body []byte, duration time.Duration) bool {
+			return re.Match(body)
+		}
+	case key == "json" && op == '=':
+		parts := strings.SplitN(value, "==", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid json check, expected path==value: %s", expr)
+		}
+		path, err := parseJSONPath(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid json path in check: %s: %s", expr, err)
+		}
+		var want interface{}
+		if json.Unmarshal([]byte(strings.TrimSpace(parts[1])), &want) != nil {
+			want = strings.TrimSpace(parts[1])
+		}
+		c.verify = func(resp *http.Response, body []byte, duration time.Duration) bool {
+			var doc interface{}
+			if json.Unmarshal(body, &doc) != nil {
+				return false
+			}
+			got, ok := path.lookup(doc)
+			return ok && reflect.DeepEqual(got, want)
+		}
+	case key == "schema" && op == '=':
+		abs, err := filepath.Abs(value)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewReferenceLoader("file://" + filepath.ToSlash(abs)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema in check: %s: %s", expr, err)
+		}
+		c.verify = func(resp *http.Response, body []byte, duration time.Duration) bool {
+			result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
+			return err == nil && result.Valid()
+		}
+	case (key == "minsize" || key == "maxsize" || key == "maxlatency") && op == '=':
+		limit, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number in check: %s", expr)
+		}
+		c.verify = func(resp *http.Response, body []byte, duration time.Duration) bool {
+			switch key {
+			case "minsize":
+				return int64(len(body)) >= limit
+			case "maxsize":
+				return int64(len(body)) <= limit
+			}
+			return duration <= time.Duration(limit)*time.Millisecond
+		}
+	default:
+		return nil, fmt.Errorf("unknown check: %s", expr)
+	}
+	return c, nil
+}
+
+// verify runs the checks of req against a response and returns the names
+// of the checks that failed
+func (req *blitzRequest) verify(resp *http.Response, body []byte, duration time.Duration) (failed []string) {
+	for _, c := range req.checks {
+		if !c.verify(resp, body, duration) {
+			failed = append(failed, c.name)
+		}
+	}
+	return
+}
+
+// hasStatusCheck tells whether req decides itself which status codes are good
+func (req *blitzRequest) hasStatusCheck() bool {
+	for _, c := range req.checks {
+		if c.status {
+			return true
+		}
+	}
+	return false
+}
+
+// A jsonPath is a parsed path like $.items[0].id: a list of object keys
+// (strings) and array indexes (ints)
+type jsonPath []interface{}
+
+var jsonPathToken = regexp.MustCompile(`^(?:\.([^.\[]+)|\[(\d+)\]|\["([^"]*)"\])`)
+
+func parseJSONPath(path string) (jsonPath, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path must start with $")
+	}
+	var parsed jsonPath
+	rest := path[1:]
+	for rest != "" {
+		m := jsonPathToken.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+		switch {
+		case m[1] != "":
+			parsed = append(parsed, m[1])
+		case m[2] != "":
+			index, _ := strconv.Atoi(m[2])
+			parsed = append(parsed, index)
+		default:
+			parsed = append(parsed, m[3])
+		}
+		rest = rest[len(m[0]):]
+	}
+	return parsed, nil
+}
+
+// lookup returns the value at the path in a decoded JSON document
+func (path jsonPath) lookup(doc interface{}) (interface{}, bool) {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = obj[step]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := doc.([]interface{})
+			if !ok || step >= len(arr) {
+				return nil, false
+			}
+			doc = arr[step]
+		}
+	}
+	return doc, true
+}
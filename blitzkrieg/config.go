@@ -3,20 +3,24 @@ package blitzkrieg
 import (
 	"bufio"
 	"bytes"
+	"code.google.com/p/go.net/publicsuffix"
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"log"
 	"math"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
 	"os"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
-	"strconv"
-	"code.google.com/p/go.net/publicsuffix"
-	"net/http/cookiejar"
 )
 
 const (
@@ -24,34 +28,99 @@ const (
 )
 
 var (
-	count          int    // Number of requests per client
-	clients        int    // Number of clients to simulate
-	duration       int    // Duration of the test
-	rate           int    // Rate limit
-	url            string // URL
-	urlsFilePath   string // Input file containing Urls
-	keepAlive      bool   // Http Keep-alive on/off flag
-	gzip           bool   // Accept gzip compression
-	needLogin      bool   // Login on/off flag - if enabled the first url from urlsFilePath is used for login
-	connectTimeout int    // Connect timeout in milliseconds
-	readTimeout    int    // Read timeout in milliseconds
-	writeTimeout   int    // Write timeout in milliseconds
-	showErr        bool   // Show errors
-	outFormat      string // Output Format
-	version        bool   // Display version
-	help           bool   // Display help
+	count          int        // Number of requests per client
+	clients        int        // Number of clients to simulate
+	duration       int        // Duration of the test
+	rate           int        // Rate limit
+	url            string     // URL
+	urlsFilePath   string     // Input file containing Urls
+	keepAlive      bool       // Http Keep-alive on/off flag
+	gzip           bool       // Accept gzip compression
+	needLogin      bool       // Login on/off flag - if enabled the first url from urlsFilePath is used for login
+	connectTimeout int        // Connect timeout in milliseconds
+	readTimeout    int        // Read timeout in milliseconds
+	writeTimeout   int        // Write timeout in milliseconds
+	showErr        bool       // Show errors
+	showSeries     bool       // Show the time series
+	outFormat      string     // Output Format
+	interval       int        // Aggregation interval in seconds
+	rawLogPath     string     // File to log every result to
+	version        bool       // Display version
+	help           bool       // Display help
+	assertions     stringList // Checks applied to every request
+	thresholds     stringList // Pass/fail thresholds
+	aborts         stringList // Conditions that stop the run early
+	samples        int        // Failed requests kept per status code and error category
+	proto          string     // Protocol: http1.1, h2, h2c or h3
+	connsPerClient int        // Connections per client
+	streams        int        // Requests in flight per client
+	zeroRTT        bool       // Send GET and HEAD as 0-RTT data with h3
+	messages       stringList // WebSocket and gRPC messages
+	expect         string     // Pattern of the WebSocket replies
+	msgRate        int        // WebSocket messages per second per connection
+	protoset       string     // File describing the gRPC services, instead of server reflection
+	events         string     // How streamed responses are split into events, if they are
+	rawFile        string     // File of bytes sent as is to the URL
+	pipeline       int        // HTTP/1.1 requests in flight per connection, pipelined
+	proxyURL       string     // Proxy every connection goes through
+	proxyEnv       bool       // Take the proxies from the environment
+	certFile       string     // Client certificate, for mTLS
+	keyFile        string     // Key of the client certificate
+	caFile         string     // CA bundle the server certificates are verified against
+	tlsMin         string     // Lowest TLS version
+	tlsMax         string     // Highest TLS version
+	ciphers        string     // Cipher suites offered, comma separated
+	sni            string     // Server name sent in place of the host of the URL
+	tlsResume      bool       // Resume TLS sessions on new connections
+	storm          bool       // Handshake storm: new connections closed after their TLS handshake
+	resolves       stringList // host:port:addr overrides of DNS
+	dnsServer      string     // DNS server queried in place of the system resolver
+	dnsCache       bool       // Cache DNS answers for their TTL
+	roundRobin     bool       // New connections go to the addresses of a host in turn
+	bindAddrs      string     // Local addresses new connections go out from in turn, comma separated
+	localPorts     string     // Range of local ports, lo-hi
+	netProfile     string     // Network conditions emulated, by name
+	netLatency     int        // Latency added to round trips in milliseconds, -1 for the profile's
+	netJitter      int        // Latency variation in milliseconds, -1 for the profile's
+	netUp          int        // Upload bandwidth in kbit/s, -1 for the profile's
+	netDown        int        // Download bandwidth in kbit/s, -1 for the profile's
+	netDrop        float64    // Chance that a connection drops per second, in %, -1 for the profile's
 )
 
+// stringList is a flag that can be given several times
+type stringList []string
+
+func (sl *stringList) String() string {
+	return strings.Join(*sl, ", ")
+}
+
+func (sl *stringList) Set(value string) error {
+	*sl = append(*sl, value)
+	return nil
+}
+
 type blitzRequest struct {
+	name   string // groups the request in reports
 	url    string
 	method string
 	header http.Header
 	body   string
+	checks []*check // assertions on the response
+	// the method of a gRPC request, resolved from the URL
+	rpc protoreflect.MethodDescriptor
+	// the file a RAW request was read from, the requests in its bytes and
+	// their methods
+	rawFile    string
+	rawCount   int
+	rawMethods []string
 }
 
 func (req *blitzRequest) getHttpRequest() (hReq *http.Request) {
 	hReq, _ = http.NewRequest(req.method, req.url, strings.NewReader(req.body))
 	hReq.Header = req.header
+	if host := req.header.Get("Host"); host != "" {
+		hReq.Host = host
+	}
 	return
 }
 
@@ -76,6 +145,8 @@ func init() {
 	flag.BoolVar(&needLogin, "login", false, "")
 	flag.BoolVar(&showErr, "e", false, "")
 	flag.BoolVar(&showErr, "err", false, "Display Errors")
+	flag.BoolVar(&showSeries, "s", false, "")
+	flag.BoolVar(&showSeries, "series", false, "Display the time series")
 	flag.IntVar(&connectTimeout, "tc", 5000, "Connect timeout in ms")
 	flag.IntVar(&connectTimeout, "timeoutcon", 5000, "")
 	flag.IntVar(&readTimeout, "tr", 5000, "Read timeout in ms")
@@ -84,6 +155,51 @@ func init() {
 	flag.IntVar(&writeTimeout, "timeoutwrite", 5000, "")
 	flag.StringVar(&outFormat, "o", "", "")
 	flag.StringVar(&outFormat, "output", "", "Output Format")
+	flag.IntVar(&interval, "i", 1, "Aggregation interval in seconds")
+	flag.IntVar(&interval, "interval", 1, "")
+	flag.StringVar(&rawLogPath, "log", "", "Log every result to this file")
+	flag.Var(&assertions, "a", "")
+	flag.Var(&assertions, "assert", "Check applied to every response")
+	flag.Var(&thresholds, "t", "")
+	flag.Var(&thresholds, "threshold", "Pass/fail threshold")
+	flag.Var(&aborts, "abort", "Condition that stops the run early")
+	flag.IntVar(&samples, "samples", 0, "Failed requests kept per status code and error category")
+	flag.StringVar(&proto, "p", protoHTTP1, "")
+	flag.StringVar(&proto, "proto", protoHTTP1, "Protocol: http1.1, h2, h2c or h3")
+	flag.IntVar(&connsPerClient, "conns", 1, "Connections per client")
+	flag.IntVar(&streams, "streams", 1, "Requests in flight per client")
+	flag.BoolVar(&zeroRTT, "0rtt", false, "Send GET and HEAD as 0-RTT data with h3")
+	flag.Var(&messages, "m", "")
+	flag.Var(&messages, "message", "WebSocket or gRPC message")
+	flag.StringVar(&expect, "expect", "", "Pattern of the WebSocket replies")
+	flag.IntVar(&msgRate, "msgrate", 0, "WebSocket messages per second per connection")
+	flag.StringVar(&protoset, "protoset", "", "File describing the gRPC services")
+	flag.StringVar(&events, "events", "", "Split streamed responses into events: sse, lines or chunks")
+	flag.StringVar(&rawFile, "raw", "", "File of bytes sent as is to the URL")
+	flag.IntVar(&pipeline, "pipeline", 0, "HTTP/1.1 requests in flight per connection, pipelined")
+	flag.StringVar(&proxyURL, "proxy", "", "Proxy every connection goes through")
+	flag.BoolVar(&proxyEnv, "proxy-env", false, "Take the proxies from HTTP_PROXY, HTTPS_PROXY, ALL_PROXY and NO_PROXY")
+	flag.StringVar(&certFile, "cert", "", "Client certificate, for mTLS")
+	flag.StringVar(&keyFile, "key", "", "Key of the client certificate")
+	flag.StringVar(&caFile, "cacert", "", "CA bundle the server certificates are verified against")
+	flag.StringVar(&tlsMin, "tlsmin", "", "Lowest TLS version")
+	flag.StringVar(&tlsMax, "tlsmax", "", "Highest TLS version")
+	flag.StringVar(&ciphers, "ciphers", "", "Cipher suites offered, comma separated")
+	flag.StringVar(&sni, "sni", "", "Server name sent in place of the host of the URL")
+	flag.BoolVar(&tlsResume, "resume", false, "Resume TLS sessions on new connections")
+	flag.BoolVar(&storm, "storm", false, "Handshake storm: every request is a new connection closed after its TLS handshake")
+	flag.Var(&resolves, "resolve", "host:port:addr[,addr] override of DNS, can be given several times")
+	flag.StringVar(&dnsServer, "dns", "", "DNS server queried in place of the system resolver")
+	flag.BoolVar(&dnsCache, "dnscache", false, "Cache DNS answers for their TTL")
+	flag.BoolVar(&roundRobin, "roundrobin", false, "New connections go to the addresses of a host in turn")
+	flag.StringVar(&bindAddrs, "bind", "", "Local addresses new connections go out from in turn, comma separated")
+	flag.StringVar(&localPorts, "ports", "", "Range of local ports new connections go out from, lo-hi")
+	flag.StringVar(&netProfile, "net", "", "Network conditions emulated: 2g, 3g, 4g or slow-wifi")
+	flag.IntVar(&netLatency, "latency", -1, "Latency added to round trips in ms")
+	flag.IntVar(&netJitter, "jitter", -1, "Latency variation in ms")
+	flag.IntVar(&netUp, "up", -1, "Upload bandwidth in kbit/s")
+	flag.IntVar(&netDown, "down", -1, "Download bandwidth in kbit/s")
+	flag.Float64Var(&netDrop, "drop", -1, "Chance that a connection drops per second, in %")
 	flag.BoolVar(&version, "v", false, "Prints the version number")
 	flag.BoolVar(&help, "h", false, "Show Help")
 
@@ -96,19 +212,100 @@ func init() {
 		fmt.Fprintf(os.Stderr, "-r,  -rate           Rate              Rate limit.\n")
 		fmt.Fprintf(os.Stderr, "-u,  -url            URL               URL to test.\n")
 		fmt.Fprintf(os.Stderr, "-f,  -file           URLs File         URLs file.\n")
+		fmt.Fprintf(os.Stderr, "                                       unix:///path/to/app.sock:/path URLs send HTTP over a Unix socket.\n")
 		fmt.Fprintf(os.Stderr, "-k,  -keep           KeepAlive         HTTP keep-alive on/off [default true].\n")
 		fmt.Fprintf(os.Stderr, "-g,  -gzip           GZip              Accept Gzip Compression [default true].\n")
+		fmt.Fprintf(os.Stderr, "-p,  -proto          Protocol          One of [http1.1, h2, h2c, h3] [default http1.1]. h2 negotiates HTTP/2\n")
+		fmt.Fprintf(os.Stderr, "                                       over TLS, h2c speaks it over cleartext with prior knowledge, h3 is QUIC.\n")
+		fmt.Fprintf(os.Stderr, "     -0rtt           ZeroRTT           Send GET and HEAD requests as 0-RTT data on resumed h3 connections.\n")
+		fmt.Fprintf(os.Stderr, "     -conns          Connections       Connections per client [default 1].\n")
+		fmt.Fprintf(os.Stderr, "     -streams        Streams           Requests in flight per client, spread over its connections [default 1].\n")
+		fmt.Fprintf(os.Stderr, "                                       They are multiplexed with h2 and h2c and queue with http1.1.\n")
+		fmt.Fprintf(os.Stderr, "     -pipeline       Depth             Pipeline HTTP/1.1 requests, keeping up to Depth in flight on each\n")
+		fmt.Fprintf(os.Stderr, "                                       connection; responses wait for the ones ahead of them (Blocked).\n")
+		fmt.Fprintf(os.Stderr, "-m,  -message        Message           WebSocket or gRPC message, may be repeated to send them in turn.\n")
+		fmt.Fprintf(os.Stderr, "                                       {{client}}, {{seq}} and {{time}} are replaced by the client and message\n")
+		fmt.Fprintf(os.Stderr, "                                       numbers and the unix time in ms. ws:// and wss:// URLs are WebSocket\n")
+		fmt.Fprintf(os.Stderr, "                                       targets, grpc:// and grpcs:// URLs ending in /package.Service/Method\n")
+		fmt.Fprintf(os.Stderr, "                                       gRPC ones, with JSON messages, all sent at once on client streams.\n")
+		fmt.Fprintf(os.Stderr, "     -expect         Pattern           Regex the WebSocket reply must match, other messages are skipped.\n")
+		fmt.Fprintf(os.Stderr, "     -msgrate        MessageRate       WebSocket messages per second per connection.\n")
+		fmt.Fprintf(os.Stderr, "     -protoset       Protoset          File describing the gRPC services (protoc --descriptor_set_out\n")
+		fmt.Fprintf(os.Stderr, "                                       --include_imports), server reflection is used without one.\n")
+		fmt.Fprintf(os.Stderr, "     -events         EventMode         Read responses as streams of events and time them, one of [sse, lines,\n")
+		fmt.Fprintf(os.Stderr, "                                       chunks]. sse splits on blank lines, lines suits NDJSON and chunks\n")
+		fmt.Fprintf(os.Stderr, "                                       counts every read of the body.\n")
+		fmt.Fprintf(os.Stderr, "     -raw            RawFile           File of bytes written as is over a connection to the host of the URL,\n")
+		fmt.Fprintf(os.Stderr, "                                       TLS for https. One response is read per request in the file. In a\n")
+		fmt.Fprintf(os.Stderr, "                                       URLs file: URL <tab> RAW <tab> file [<tab> options].\n")
+		fmt.Fprintf(os.Stderr, "     -proxy          ProxyURL          Proxy every connection goes through, http:// and https:// ones with\n")
+		fmt.Fprintf(os.Stderr, "                                       CONNECT, socks5:// and socks5h:// (proxy resolves) ones with SOCKS5.\n")
+		fmt.Fprintf(os.Stderr, "                                       user:password@ in the URL authenticates. Not for h3.\n")
+		fmt.Fprintf(os.Stderr, "     -proxy-env      ProxyEnv          Take the proxies from HTTP_PROXY, HTTPS_PROXY, ALL_PROXY and NO_PROXY.\n")
+		fmt.Fprintf(os.Stderr, "     -cert           CertFile          Client certificate for mTLS, PEM, along with its key unless -key is given.\n")
+		fmt.Fprintf(os.Stderr, "     -key            KeyFile           Key of the client certificate, PEM.\n")
+		fmt.Fprintf(os.Stderr, "     -cacert         CAFile            CA bundle the server certificates are verified against, PEM, those of\n")
+		fmt.Fprintf(os.Stderr, "                                       https proxies too. They are not verified without one.\n")
+		fmt.Fprintf(os.Stderr, "     -tlsmin         Version           Lowest TLS version, one of [1.0, 1.1, 1.2, 1.3].\n")
+		fmt.Fprintf(os.Stderr, "     -tlsmax         Version           Highest TLS version, one of [1.0, 1.1, 1.2, 1.3].\n")
+		fmt.Fprintf(os.Stderr, "     -ciphers        CipherSuites      Comma separated cipher suites offered up to TLS 1.2, by their Go names\n")
+		fmt.Fprintf(os.Stderr, "                                       (TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256). TLS 1.3 ones are not chosen.\n")
+		fmt.Fprintf(os.Stderr, "     -sni            ServerName        Server name sent and verified in place of the host of the URL.\n")
+		fmt.Fprintf(os.Stderr, "     -resume         Resume            Resume TLS sessions on the new connections of a client [default false].\n")
+		fmt.Fprintf(os.Stderr, "                                       h3 always resumes them.\n")
+		fmt.Fprintf(os.Stderr, "     -storm          Storm             Handshake storm: every request opens a new connection, closed once its\n")
+		fmt.Fprintf(os.Stderr, "                                       TLS handshake is done, to load TLS termination. Nothing is sent over it.\n")
+		fmt.Fprintf(os.Stderr, "     -resolve        Override          host:port:addr[,addr] connections to host:port go to, in place of its\n")
+		fmt.Fprintf(os.Stderr, "                                       DNS addresses, as with curl. Can be given several times.\n")
+		fmt.Fprintf(os.Stderr, "     -dns            DNSServer         DNS server host names are resolved with, port 53 by default.\n")
+		fmt.Fprintf(os.Stderr, "     -dnscache       DNSCache          Cache DNS answers for their TTL [default false]. Without it every new\n")
+		fmt.Fprintf(os.Stderr, "                                       connection looks its host up.\n")
+		fmt.Fprintf(os.Stderr, "     -roundrobin     RoundRobin        New connections go to the addresses of a host in turn, rather than to\n")
+		fmt.Fprintf(os.Stderr, "                                       the first that answers [default false].\n")
+		fmt.Fprintf(os.Stderr, "     -bind           LocalAddresses    Comma separated local IP addresses new connections go out from in\n")
+		fmt.Fprintf(os.Stderr, "                                       turn, to get past the ephemeral ports of one address.\n")
+		fmt.Fprintf(os.Stderr, "     -ports          PortRange         Range of local ports new connections go out from, lo-hi. Taken ports\n")
+		fmt.Fprintf(os.Stderr, "                                       are skipped; failures to get one count as \"ports exhausted\".\n")
+		fmt.Fprintf(os.Stderr, "     -net            NetProfile        Network conditions emulated on every connection, one of [2g, 3g, 4g,\n")
+		fmt.Fprintf(os.Stderr, "                                       slow-wifi], for the servers to see slow clients. Not for h3.\n")
+		fmt.Fprintf(os.Stderr, "     -latency        Latency           Latency added to every round trip in ms, over the profile's.\n")
+		fmt.Fprintf(os.Stderr, "     -jitter         Jitter            The latency varies by up to this many ms either way.\n")
+		fmt.Fprintf(os.Stderr, "     -up             Upload            Upload bandwidth of every connection in kbit/s, 0 for no limit.\n")
+		fmt.Fprintf(os.Stderr, "     -down           Download          Download bandwidth of every connection in kbit/s, 0 for no limit.\n")
+		fmt.Fprintf(os.Stderr, "     -drop           DropRate          Chance in %% that a connection drops, per second it is open. Drops\n")
+		fmt.Fprintf(os.Stderr, "                                       count as \"emulated drop\" errors.\n")
 		fmt.Fprintf(os.Stderr, "-l,  -login          Login             Do login on/off.\n")
 		fmt.Fprintf(os.Stderr, "-tc, -timeoutcon     ConnectTimeout    Connect timeout in ms [default 5000].\n")
 		fmt.Fprintf(os.Stderr, "-tr, -timeoutread    ReadTimeout       Read timeout in ms [default 5000].\n")
 		fmt.Fprintf(os.Stderr, "-tw, -timeoutwrite   WriteTimeout      Write timeout in ms [default 5000].\n")
-		fmt.Fprintf(os.Stderr, "-o,  -output         OutputFormat      [graph].\n")
+		fmt.Fprintf(os.Stderr, "-o,  -output         OutputFormat      Comma separated list of [graph, json, csv].\n")
+		fmt.Fprintf(os.Stderr, "-i,  -interval       Interval          Aggregation interval in seconds [default 1].\n")
+		fmt.Fprintf(os.Stderr, "     -log            LogFile           Log every result to this file.\n")
+		fmt.Fprintf(os.Stderr, "     -samples        Samples           Failed requests kept per status code and error category,\n")
+		fmt.Fprintf(os.Stderr, "                                       written to a HAR file with a curl command for each.\n")
 		fmt.Fprintf(os.Stderr, "-e,  -err            ShowErr           Display Errors.\n")
+		fmt.Fprintf(os.Stderr, "-a,  -assert         Check             Check applied to every response, may be repeated\n")
+		fmt.Fprintf(os.Stderr, "                                       (status=200,2xx header=Name~re body=text body~re json=$.a==1\n")
+		fmt.Fprintf(os.Stderr, "                                       schema=file.json minsize=N maxsize=N maxlatency=ms).\n")
+		fmt.Fprintf(os.Stderr, "-t,  -threshold      Threshold         Pass/fail condition checked at the end, may be repeated\n")
+		fmt.Fprintf(os.Stderr, "                                       (p99<300ms mean<1s max<2s error_rate<0.5%% rps>1000 [name]p95<500ms).\n")
+		fmt.Fprintf(os.Stderr, "     -abort          Condition         Stop the run early when a threshold style breach lasts, may be repeated\n")
+		fmt.Fprintf(os.Stderr, "                                       (error_rate>50%% for 10s p99>5s for 30s consecutive_refused>=100).\n")
+		fmt.Fprintf(os.Stderr, "-s,  -series         ShowSeries        Display the time series.\n")
 		fmt.Fprintf(os.Stderr, "-v,  -version        Version           Prints the version number.\n")
 		fmt.Fprintf(os.Stderr, "-h,  -help           Help              Prints this output.\n")
+		fmt.Fprintf(os.Stderr, "Exit codes: %d ok, %d threshold failed, %d configuration error, %d aborted.\n",
+			ExitOK, ExitThresholdFailed, ExitConfigError, ExitAborted)
 	}
 }
 
+// configError reports a problem with the options or the request file and
+// exits with ExitConfigError
+func configError(format string, v ...interface{}) {
+	log.Printf(format, v...)
+	os.Exit(ExitConfigError)
+}
+
 // showVersion displays the version number
 func showVersion() {
 	fmt.Println("blitz", VERSION)
@@ -129,11 +326,11 @@ func NewBlitz() (blitz *Blitz) {
 	}
 	if urlsFilePath == "" && url == "" {
 		flag.Usage()
-		os.Exit(0)
+		os.Exit(ExitConfigError)
 	}
 	if count == -1 && duration == -1 {
 		flag.Usage()
-		os.Exit(0)
+		os.Exit(ExitConfigError)
 	}
 	blitz = &Blitz{
 		requests:       make([]*blitzRequest, 0),
@@ -145,32 +342,190 @@ func NewBlitz() (blitz *Blitz) {
 		connectTimeout: connectTimeout,
 		readTimeout:    readTimeout,
 		writeTimeout:   writeTimeout,
+		interval:       time.Second,
+		rawLogPath:     rawLogPath,
+		proto:          proto,
+		connsPerClient: connsPerClient,
+		streams:        streams,
+		zeroRTT:        zeroRTT,
+		messages:       messages,
+		expect:         expect,
+		msgRate:        msgRate,
+		events:         events,
+		pipeline:       pipeline,
+		storm:          storm,
+	}
+	switch proto {
+	case protoHTTP1, protoH2, protoH2C, protoH3:
+	default:
+		configError("Unknown protocol: %s", proto)
+	}
+	if connsPerClient < 1 || streams < 1 {
+		configError("Connections and streams per client must be at least 1")
+	}
+	if zeroRTT && proto != protoH3 {
+		configError("0-RTT needs the h3 protocol")
+	}
+	if pipeline < 0 || pipeline > 0 && (proto != protoHTTP1 || !keepAlive) {
+		configError("Pipelining needs the http1.1 protocol with keep-alive on")
+	}
+	switch events {
+	case "", eventsSSE, eventsLines, eventsChunks:
+	default:
+		configError("Unknown event mode: %s", events)
+	}
+	if proxyURL != "" {
+		var err error
+		if allProxy, err = parseProxy(proxyURL); err != nil {
+			configError("Error parsing proxy: %s", err)
+		}
+	}
+	if (allProxy != nil || proxyEnv) && proto == protoH3 {
+		configError("Proxies tunnel TCP, h3 cannot go through them")
+	}
+	if storm && (proto == protoH3 || pipeline > 0) {
+		configError("Handshake storms are over TCP, without pipelining")
+	}
+	dns, err := newResolver(resolves, dnsServer, dnsCache, roundRobin)
+	if err != nil {
+		configError("Error setting up DNS: %s", err)
+	}
+	targetDNS = dns
+	if err := parseBind(bindAddrs, localPorts); err != nil {
+		configError("Error setting up local addresses: %s", err)
+	}
+	if err := loadNetem(); err != nil {
+		configError("Error setting up network emulation: %s", err)
+	}
+	if emulated != nil && proto == protoH3 {
+		configError("Network emulation is over TCP, h3 cannot use it")
+	}
+	if err := loadTLS(); err != nil {
+		configError("Error setting up TLS: %s", err)
+	}
+	if proto == protoH3 && targetTLS.MaxVersion != 0 && targetTLS.MaxVersion < tls.VersionTLS13 {
+		configError("h3 needs TLS 1.3")
+	}
+	if interval > 0 {
+		blitz.interval = time.Duration(interval) * time.Second
 	}
 	if count != -1 {
 		blitz.count = count
 	}
+	if samples > 0 {
+		blitz.samples = newSampler(samples, gzip)
+	}
 
 	if urlsFilePath != "" {
 		requests, err := readFile(urlsFilePath)
 		if err != nil {
-			log.Fatalf("Error reading file:%s Error: ", urlsFilePath, err)
-			os.Exit(0)
+			configError("Error reading file:%s Error: %s", urlsFilePath, err)
 		}
 		if len(requests) == 0 {
-			log.Fatalf("File has insufficient number of lines")
-			os.Exit(0)
+			configError("File has insufficient number of lines")
 		}
 		blitz.requests = requests
 	}
 
 	if url != "" {
-		blitz.requests = append(blitz.requests, &blitzRequest{url: url, method: "GET"})
+		method := "GET"
+		if isWebSocket(url) {
+			method = "WS"
+		} else if isGRPC(url) {
+			method = "GRPC"
+		}
+		req := &blitzRequest{url: url, method: method, header: make(http.Header)}
+		unixRequest(req)
+		if err := proxyRequest(req); err != nil {
+			configError("Error finding proxy: %s", err)
+		}
+		req.name = deriveName(method, req.url)
+		if rawFile != "" {
+			req.method, req.body = "RAW", rawFile
+			if err := loadRaw(req); err != nil {
+				configError("Error reading raw request: %s", err)
+			}
+		}
+		blitz.requests = append(blitz.requests, req)
+	}
+	for _, req := range blitz.requests {
+		if storm {
+			if err := checkStorm(req); err != nil {
+				configError("Error setting up the handshake storm: %s", err)
+			}
+			continue
+		}
+		if isWebSocket(req.url) && req.body == "" && len(messages) == 0 {
+			configError("WebSocket request %s has no message, give one with -m or in the file", req.url)
+		}
+	}
+	// the placeholders are filled in before every reply is matched, the
+	// pattern is compiled once when there are none
+	if expectRe, err := regexp.Compile(renderMessage(expect, 1, 1)); err != nil {
+		configError("Error parsing expect pattern: %s", err)
+	} else if expect != "" && renderMessage(expect, 1, 1) == expect {
+		blitz.expectRe = expectRe
+	}
+	if err := resolveGRPC(blitz.requests, protoset, blitz.dialer(), time.Duration(connectTimeout+readTimeout)*time.Millisecond); err != nil {
+		configError("Error resolving gRPC method: %s", err)
+	}
+	for _, req := range blitz.requests {
+		if req.rpc == nil {
+			continue
+		}
+		templates := messages
+		if req.body != "" {
+			templates = []string{req.body}
+		}
+		for seq := range templates {
+			if _, err := grpcMessages(req.rpc, templates, 0, int64(seq)); err != nil {
+				configError("Error parsing gRPC %s", err)
+			}
+		}
 	}
 
 	if duration != -1 {
 		blitz.duration = duration
 	}
 
+	for _, assertion := range assertions {
+		c, err := parseCheck(assertion)
+		if err != nil {
+			configError("Error parsing check: %s", err)
+		}
+		for _, req := range blitz.requests {
+			req.checks = append(req.checks, c)
+		}
+	}
+	for _, req := range blitz.requests {
+		switch {
+		case len(req.checks) == 0:
+		case storm:
+			configError("Checks do not apply to a handshake storm, which sends no requests")
+		case req.rpc != nil:
+			configError("Checks do not apply to gRPC request %s", req.url)
+		}
+		for _, c := range req.checks {
+			if c.http && isWebSocket(req.url) {
+				configError("Check %s needs an HTTP response, WebSocket request %s only has replies", c.name, req.url)
+			}
+		}
+	}
+	for _, expr := range thresholds {
+		t, err := parseThreshold(expr)
+		if err != nil {
+			configError("Error parsing threshold: %s", err)
+		}
+		blitz.thresholds = append(blitz.thresholds, t)
+	}
+	for _, expr := range aborts {
+		a, err := parseAbort(expr)
+		if err != nil {
+			configError("Error parsing abort condition: %s", err)
+		}
+		blitz.aborts = append(blitz.aborts, a)
+	}
+
 	if os.Getenv("GOMAXPROCS") == "" {
 		runtime.GOMAXPROCS(runtime.NumCPU())
 	}
@@ -183,6 +538,7 @@ func readFile(path string) (requests []*blitzRequest, err error) {
 	var (
 		file         *os.File
 		line         string
+		name         string
 		arr          []string
 		length       int
 		req          *blitzRequest
@@ -201,28 +557,60 @@ func readFile(path string) (requests []*blitzRequest, err error) {
 			continue
 		}
 		arr = strings.Split(line, "\t")
+		name = ""
+		if strings.HasPrefix(arr[0], "[") && strings.HasSuffix(arr[0], "]") && len(arr) > 1 {
+			name = strings.TrimSpace(arr[0][1 : len(arr[0])-1])
+			arr = arr[1:]
+		}
 		length = len(arr)
 		req = &blitzRequest{url: arr[0], method: "GET", header: make(http.Header)}
+		if isWebSocket(req.url) {
+			req.method = "WS"
+		} else if isGRPC(req.url) {
+			req.method = "GRPC"
+		}
 		if length > 1 {
 			req.method = arr[1]
 		}
 		switch req.method {
+		case "WS", "GRPC", "RAW":
+			switch length {
+			case 4:
+				req.header, req.checks = parseOptions(arr[3])
+				fallthrough
+			case 3:
+				req.body = arr[2]
+			}
 		case "POST":
 			switch length {
 			case 4:
-				req.header = parseHeaders(arr[3])
+				req.header, req.checks = parseOptions(arr[3])
 				fallthrough
 			case 3:
 				req.header.Add("Content-Type", "application/x-www-form-urlencoded")
-    			req.header.Add("Content-Length", strconv.Itoa(len(arr[2])))
+				req.header.Add("Content-Length", strconv.Itoa(len(arr[2])))
 				req.body = arr[2]
 			}
 		case "GET":
 			if length > 2 {
-				req.header = parseHeaders(arr[2])
+				req.header, req.checks = parseOptions(arr[2])
+			}
+		}
+		unixRequest(req)
+		if err = proxyRequest(req); err != nil {
+			return
+		}
+		if req.method == "RAW" {
+			if err = loadRaw(req); err != nil {
+				return
 			}
 		}
 		req.header.Set("User-Agent", "blitz "+VERSION)
+		if name != "" {
+			req.name = name
+		} else if req.name == "" {
+			req.name = deriveName(req.method, req.url)
+		}
 
 		if loginCookies != "" {
 			tmpCookie = req.header.Get("Cookie")
@@ -251,22 +639,80 @@ func readFile(path string) (requests []*blitzRequest, err error) {
 	return
 }
 
-// parseHeaders parses the header string and returns an
-// http.Header
-func parseHeaders(headerStr string) (header http.Header) {
-	var (
-		hArr []string
-	)
+// deriveName names a request after its method and path, with the path
+// segments that look like ids replaced by ":id" so that /users/1 and
+// /users/2 end up in the same group
+func deriveName(method string, rawurl string) string {
+	path := rawurl
+	if u, err := neturl.Parse(rawurl); err == nil {
+		path = u.Path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if isIdSegment(segment) {
+			segments[i] = ":id"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if path == "" {
+		path = "/"
+	}
+	return method + " " + path
+}
+
+// isIdSegment tells whether a path segment looks like a numeric id, a uuid or a hex hash
+func isIdSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	digits, hex := 0, 0
+	for _, c := range segment {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			hex++
+		case c == '-':
+		default:
+			return false
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	return hex == 0 || len(segment) >= 8
+}
+
+// optionFlag matches the start of a -H (header) or -A (check) option
+var optionFlag = regexp.MustCompile(`(?:^|\s)-([HA])\s*`)
+
+// parseOptions parses the option string of a request, made of curl style
+// -H 'Name: value' headers and -A 'check' assertions
+func parseOptions(optStr string) (header http.Header, checks []*check) {
 	header = make(http.Header)
-	splitArr := strings.Split(strings.TrimLeft(headerStr, "-H"), "-H")
-	for _, str := range splitArr {
-		str = strings.Trim(str, " '")
-		hArr = strings.SplitN(str, ":", 2)
-		if len(hArr) > 1 {
+	matches := optionFlag.FindAllStringSubmatchIndex(optStr, -1)
+	if len(matches) == 0 || strings.TrimSpace(optStr[:matches[0][0]]) != "" {
+		configError("Error parsing header string: %s", optStr)
+	}
+	for i, match := range matches {
+		end := len(optStr)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		str := strings.Trim(optStr[match[1]:end], " '")
+		switch optStr[match[2]:match[3]] {
+		case "H":
+			hArr := strings.SplitN(str, ":", 2)
+			if len(hArr) < 2 {
+				configError("Error parsing header string: %s", optStr)
+			}
 			header.Set(strings.TrimSpace(hArr[0]), strings.TrimSpace(hArr[1]))
-		} else {
-			log.Fatalf("Error parsing header string: %s", headerStr)
-			os.Exit(0)
+		case "A":
+			c, err := parseCheck(str)
+			if err != nil {
+				configError("Error parsing check: %s", err)
+			}
+			checks = append(checks, c)
 		}
 	}
 	return
@@ -278,15 +724,17 @@ func doLogin(req *blitzRequest) ([]*http.Cookie, error) {
 	}
 	jar, cerr := cookiejar.New(&options)
 	if cerr != nil {
-		log.Fatal(cerr)
+		configError("Error creating cookie jar: %s", cerr)
 	}
 	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig: targetTLS.Clone(),
 	}
 	tr.Dial = func(network string, address string) (conn net.Conn, err error) {
-		return net.DialTimeout(network, address, time.Duration(connectTimeout)*time.Millisecond)
+		dialer := &net.Dialer{Timeout: time.Duration(connectTimeout) * time.Millisecond}
+		conn, _, err = dialTarget(context.Background(), dialer, network, address)
+		return
 	}
-	client := &http.Client{Transport: tr, Jar:jar}
+	client := &http.Client{Transport: tr, Jar: jar}
 	resp, err := client.Do(req.getHttpRequest())
 	if resp != nil && err == nil {
 		return resp.Cookies(), nil
@@ -0,0 +1,151 @@
+package blitzkrieg
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// connStats counts connections over the whole test. It is shared by every
+// BlitzConn, so all counters are updated atomically.
+type connStats struct {
+	open         int64 // currently open connections
+	total        int64 // connections opened since the start
+	serverClosed int64 // connections the server closed on us
+	bytesIn      int64 // bytes received on the wire, TLS and framing included
+	bytesOut     int64 // bytes sent on the wire
+}
+
+func (cs *connStats) opened() {
+	atomic.AddInt64(&cs.open, 1)
+	atomic.AddInt64(&cs.total, 1)
+}
+
+// snapshot returns a copy of the counters
+func (cs *connStats) snapshot() connStats {
+	return connStats{
+		open:         atomic.LoadInt64(&cs.open),
+		total:        atomic.LoadInt64(&cs.total),
+		serverClosed: atomic.LoadInt64(&cs.serverClosed),
+		bytesIn:      atomic.LoadInt64(&cs.bytesIn),
+		bytesOut:     atomic.LoadInt64(&cs.bytesOut),
+	}
+}
+
+type BlitzConn struct {
+	net.Conn
+	readTimeout  time.Duration // 0 once reads may wait indefinitely
+	writeTimeout time.Duration
+	stats        *connStats
+	closed       int32 // set once the connection is closed, by either side
+	streams      int32 // requests in flight on the connection
+	// bytes on the wire of this connection, each way
+	bytesIn, bytesOut int64
+	// how long the proxy took to open the tunnel, if there is one
+	proxy time.Duration
+	// emulated network conditions, nil for none
+	netem *netConditions
+	// set by writes, the next read waits out the rest of the emulated
+	// latency, downLag in nanoseconds, while the first write of a turn waits
+	// out its upload half
+	turn    int32
+	downLag int64
+	// when the emulated link is free again, each way
+	upBusy, downBusy time.Time
+	// drops the connection once its emulated lifetime is over
+	dropTimer *time.Timer
+	dropped   int32
+}
+
+// blitzConnOf finds the BlitzConn under conn, which the transport may have
+// wrapped in TLS
+func blitzConnOf(conn net.Conn) *BlitzConn {
+	for {
+		switch c := conn.(type) {
+		case *BlitzConn:
+			return c
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return nil
+		}
+	}
+}
+
+func (blitzConn *BlitzConn) Read(b []byte) (n int, err error) {
+	if blitzConn.netem != nil {
+		return blitzConn.emulatedRead(b)
+	}
+	return blitzConn.read(b)
+}
+
+func (blitzConn *BlitzConn) read(b []byte) (n int, err error) {
+	len, err := blitzConn.Conn.Read(b)
+	atomic.AddInt64(&blitzConn.bytesIn, int64(len))
+	atomic.AddInt64(&blitzConn.stats.bytesIn, int64(len))
+	if err == nil && blitzConn.readTimeout > 0 {
+		blitzConn.Conn.SetReadDeadline(time.Now().Add(blitzConn.readTimeout))
+	} else if err == io.EOF && atomic.CompareAndSwapInt32(&blitzConn.closed, 0, 1) {
+		atomic.AddInt64(&blitzConn.stats.open, -1)
+		atomic.AddInt64(&blitzConn.stats.serverClosed, 1)
+	}
+	return len, err
+}
+
+func (blitzConn *BlitzConn) Write(b []byte) (n int, err error) {
+	if blitzConn.netem != nil {
+		return blitzConn.emulatedWrite(b)
+	}
+	return blitzConn.write(b)
+}
+
+func (blitzConn *BlitzConn) write(b []byte) (n int, err error) {
+	len, err := blitzConn.Conn.Write(b)
+	atomic.AddInt64(&blitzConn.bytesOut, int64(len))
+	atomic.AddInt64(&blitzConn.stats.bytesOut, int64(len))
+	if err == nil {
+		blitzConn.Conn.SetWriteDeadline(time.Now().Add(blitzConn.writeTimeout))
+	}
+	return len, err
+}
+
+func (blitzConn *BlitzConn) Close() error {
+	if blitzConn.dropTimer != nil {
+		blitzConn.dropTimer.Stop()
+	}
+	if atomic.CompareAndSwapInt32(&blitzConn.closed, 0, 1) {
+		atomic.AddInt64(&blitzConn.stats.open, -1)
+	}
+	return blitzConn.Conn.Close()
+}
+
+// responseHeaderSize estimates the size of the status line and headers of
+// resp as they were sent by the server, 0 unless it came over http1.1: h2 and
+// h3 compress their headers
+func responseHeaderSize(resp *http.Response) int64 {
+	if resp.ProtoMajor != 1 {
+		return 0
+	}
+	size := len(resp.Proto) + len(resp.Status) + 3
+	return int64(size + headerSize(resp.Header))
+}
+
+// requestHeaderSize estimates the size of the request line and headers of req
+// as they are sent over http1.1
+func requestHeaderSize(req *http.Request) int64 {
+	size := len(req.Method) + len(req.URL.RequestURI()) + len("HTTP/1.1") + 4
+	size += len("Host: ") + len(req.URL.Host) + 2
+	return int64(size + headerSize(req.Header))
+}
+
+func headerSize(header http.Header) int {
+	size := 2 // the blank line ending the headers
+	for key, values := range header {
+		for _, value := range values {
+			size += len(key) + len(value) + 4
+		}
+	}
+	return size
+}
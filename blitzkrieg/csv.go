@@ -0,0 +1,58 @@
+package blitzkrieg
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"time"
+)
+
+// writeCSV writes the time series of the report to a csv file named after
+// the current time
+func writeCSV(report *report) {
+	fileName := time.Now().Format("2006-01-02-15-04-05.csv")
+	fo, err := os.Create(fileName)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := fo.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	format := func(f float64) string { return strconv.FormatFloat(f, 'f', 5, 64) }
+	w := csv.NewWriter(fo)
+	w.Write([]string{"elapsed", "requests", "success", "errors", "rate", "bytes", "mean", "p50", "p90", "p99", "max", "open", "new", "reused", "closed", "wire_in", "wire_out",
+		"events", "first_event_p50", "first_event_p99", "event_gap_p50", "event_gap_p99"})
+	for _, stat := range report.series {
+		w.Write([]string{
+			format(stat.elapsed),
+			strconv.FormatInt(stat.requests, 10),
+			strconv.FormatInt(stat.success, 10),
+			strconv.FormatInt(stat.requests-stat.success, 10),
+			format(stat.rate),
+			strconv.FormatInt(stat.bytes, 10),
+			format(stat.mean),
+			format(stat.p50),
+			format(stat.p90),
+			format(stat.p99),
+			format(stat.max),
+			strconv.FormatInt(stat.openConns, 10),
+			strconv.FormatInt(stat.newConns, 10),
+			strconv.FormatInt(stat.reusedConns, 10),
+			strconv.FormatInt(stat.serverClosed, 10),
+			strconv.FormatInt(stat.wireIn, 10),
+			strconv.FormatInt(stat.wireOut, 10),
+			strconv.FormatInt(stat.events, 10),
+			format(stat.firstEventP50),
+			format(stat.firstEventP99),
+			format(stat.eventGapP50),
+			format(stat.eventGapP99),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		panic(err)
+	}
+}
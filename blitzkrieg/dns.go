@@ -0,0 +1,355 @@
+package blitzkrieg
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/net/dns/dnsmessage"
+	"io"
+	"math/rand"
+	"net"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A resolver looks up the addresses of the targets when the DNS options are
+// set: the -resolve overrides first, then the answers cached for their TTL,
+// then the DNS server, the one of -dns or else the system's. Without a
+// server or a cache the system resolver does the lookups.
+type resolver struct {
+	sync.Mutex
+	overrides  map[string][]net.IP   // by host:port
+	server     string                // DNS server queried, "" for the system resolver
+	explicit   bool                  // whether the server was given, or is the system's
+	caching    bool                  // whether answers are kept for their TTL
+	cache      map[string]*dnsAnswer // by host
+	roundRobin bool                  // whether new connections go to the addresses of a host in turn
+	next       map[string]int        // next address of each host:port, with round robin
+	dialed     map[string]int64      // connections by address
+}
+
+// A dnsAnswer is a cached answer of the DNS server
+type dnsAnswer struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// targetDNS is the resolver of the DNS options, nil without them
+var targetDNS *resolver
+
+// newResolver returns the resolver of the DNS options, nil when none is set
+func newResolver(overrides []string, server string, caching bool, roundRobin bool) (*resolver, error) {
+	if len(overrides) == 0 && server == "" && !caching && !roundRobin {
+		return nil, nil
+	}
+	r := &resolver{
+		overrides:  make(map[string][]net.IP),
+		server:     server,
+		explicit:   server != "",
+		caching:    caching,
+		cache:      make(map[string]*dnsAnswer),
+		roundRobin: roundRobin,
+		next:       make(map[string]int),
+		dialed:     make(map[string]int64),
+	}
+	for _, override := range overrides {
+		// host:port:addr[,addr]..., IPv6 addresses may be bracketed
+		parts := strings.SplitN(override, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("%s: overrides are host:port:addr[,addr]", override)
+		}
+		key := net.JoinHostPort(parts[0], parts[1])
+		for _, addr := range strings.Split(parts[2], ",") {
+			ip := net.ParseIP(strings.Trim(addr, "[]"))
+			if ip == nil {
+				return nil, fmt.Errorf("%s: %s is not an IP address", override, addr)
+			}
+			r.overrides[key] = append(r.overrides[key], ip)
+		}
+	}
+	if r.server == "" && caching {
+		// the TTLs only come with the answers of the server itself
+		if r.server = systemNameserver(); r.server == "" {
+			return nil, fmt.Errorf("no name server in /etc/resolv.conf to cache the answers of, set one with -dns")
+		}
+	}
+	if r.server != "" {
+		if _, _, err := net.SplitHostPort(r.server); err != nil {
+			r.server = net.JoinHostPort(strings.Trim(r.server, "[]"), "53")
+		}
+	}
+	return r, nil
+}
+
+// systemNameserver returns the first name server of /etc/resolv.conf, ""
+// if there is none
+func systemNameserver() string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 1 && fields[0] == "nameserver" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// lookup returns the addresses of host for connections to port, reporting
+// the lookup to the trace of ctx the way net.Dialer does
+func (r *resolver) lookup(ctx context.Context, host string, port string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	if r != nil {
+		if ips, ok := r.overrides[net.JoinHostPort(host, port)]; ok {
+			return ips, nil
+		}
+	}
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	ips, err := r.resolve(ctx, host)
+	if trace != nil && trace.DNSDone != nil {
+		addrs := make([]net.IPAddr, len(ips))
+		for i, ip := range ips {
+			addrs[i] = net.IPAddr{IP: ip}
+		}
+		trace.DNSDone(httptrace.DNSDoneInfo{Addrs: addrs, Err: err})
+	}
+	return ips, err
+}
+
+// resolve asks the DNS server for the addresses of host, unless they are
+// cached. Names the system's server does not know, like those of
+// /etc/hosts, are left to the system resolver and not cached.
+func (r *resolver) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if r != nil && r.caching {
+		r.Lock()
+		answer := r.cache[host]
+		r.Unlock()
+		if answer != nil && time.Now().Before(answer.expires) {
+			return answer.ips, nil
+		}
+	}
+	var dnsErr *net.DNSError
+	if r != nil && r.server != "" {
+		ips, ttl, err := queryDNS(ctx, r.server, host)
+		if err == nil && r.caching {
+			r.Lock()
+			r.cache[host] = &dnsAnswer{ips: ips, expires: time.Now().Add(ttl)}
+			r.Unlock()
+		}
+		if err == nil || r.explicit || !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			return ips, err
+		}
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	return ips, err
+}
+
+// pick returns the index of the address of ips a new connection to address
+// tries first: the next one with round robin, the first one otherwise
+func (r *resolver) pick(address string, ips []net.IP) int {
+	if r == nil || !r.roundRobin {
+		return 0
+	}
+	r.Lock()
+	defer r.Unlock()
+	i := r.next[address] % len(ips)
+	r.next[address] = i + 1
+	return i
+}
+
+// connected counts a connection to address
+func (r *resolver) connected(address string) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	r.dialed[address]++
+	r.Unlock()
+}
+
+// dialContext dials address with dialer from the source addresses and
+// ports, through the resolver if there is one. Like net.Dialer, it tries the
+// addresses of the host until one connects, starting from the one pick
+// returns.
+func (r *resolver) dialContext(ctx context.Context, dialer *net.Dialer, network string, address string) (net.Conn, error) {
+	if r == nil {
+		return dialFrom(ctx, dialer, network, address)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := r.lookup(ctx, host, port)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+	}
+	start := r.pick(address, ips)
+	var firstErr error
+	for i := range ips {
+		target := net.JoinHostPort(ips[(start+i)%len(ips)].String(), port)
+		conn, err := dialFrom(ctx, dialer, network, target)
+		if err == nil {
+			r.connected(target)
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// queryDNS asks server for the A and AAAA records of host. It returns the
+// addresses, IPv4 first, and the lowest TTL of the answers.
+func queryDNS(ctx context.Context, server string, host string) ([]net.IP, time.Duration, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return nil, 0, &net.DNSError{Err: err.Error(), Name: host, Server: server}
+	}
+	var (
+		ips []net.IP
+		ttl uint32 = 1<<32 - 1
+	)
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		query := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: uint16(rand.Uint32()), RecursionDesired: true},
+			Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+		}
+		resp, err := exchangeDNS(ctx, server, query)
+		if err != nil {
+			dnsErr := &net.DNSError{Err: err.Error(), Name: host, Server: server}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				dnsErr.IsTimeout = true
+			}
+			return nil, 0, dnsErr
+		}
+		switch resp.RCode {
+		case dnsmessage.RCodeSuccess:
+		case dnsmessage.RCodeNameError:
+			return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: server, IsNotFound: true}
+		default:
+			return nil, 0, &net.DNSError{Err: "server answered " + resp.RCode.String(), Name: host, Server: server}
+		}
+		for _, answer := range resp.Answers {
+			if answer.Header.TTL < ttl {
+				ttl = answer.Header.TTL
+			}
+			switch body := answer.Body.(type) {
+			case *dnsmessage.AResource:
+				ips = append(ips, net.IP(body.A[:]))
+			case *dnsmessage.AAAAResource:
+				ips = append(ips, net.IP(body.AAAA[:]))
+			}
+		}
+	}
+	if len(ips) == 0 {
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: server, IsNotFound: true}
+	}
+	return ips, time.Duration(ttl) * time.Second, nil
+}
+
+// exchangeDNS sends query to server over UDP, and again over TCP when the
+// answer is truncated. Over UDP, datagrams that do not answer the query,
+// like late replies to earlier ones, are skipped until the deadline.
+func exchangeDNS(ctx context.Context, server string, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: time.Duration(connectTimeout) * time.Millisecond}
+	for _, network := range []string{"udp", "tcp"} {
+		conn, err := dialer.DialContext(ctx, network, server)
+		if err != nil {
+			return nil, err
+		}
+		conn.SetDeadline(time.Now().Add(time.Duration(readTimeout) * time.Millisecond))
+		var resp *dnsmessage.Message
+		if network == "udp" {
+			resp, err = exchangeUDP(conn, packed, query)
+		} else {
+			resp, err = exchangeTCP(conn, packed, query)
+		}
+		conn.Close()
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Header.Truncated {
+			return resp, nil
+		}
+	}
+	return nil, errors.New("DNS response truncated over TCP")
+}
+
+// exchangeUDP writes the packed query over conn and reads datagrams until
+// one answers it
+func exchangeUDP(conn net.Conn, packed []byte, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	if _, err := conn.Write(packed); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var resp dnsmessage.Message
+		if resp.Unpack(buf[:n]) == nil && answers(&resp, query) {
+			return &resp, nil
+		}
+	}
+}
+
+// exchangeTCP writes the packed query over conn and reads the response, both
+// prefixed with their length
+func exchangeTCP(conn net.Conn, packed []byte, query dnsmessage.Message) (*dnsmessage.Message, error) {
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...)); err != nil {
+		return nil, err
+	}
+	size := make([]byte, 2)
+	if _, err := io.ReadFull(conn, size); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, err
+	}
+	if !answers(&resp, query) {
+		return nil, errors.New("mismatched DNS response")
+	}
+	return &resp, nil
+}
+
+// answers tells whether resp is the response to query
+func answers(resp *dnsmessage.Message, query dnsmessage.Message) bool {
+	if !resp.Header.Response || resp.Header.ID != query.Header.ID || len(resp.Questions) != 1 {
+		return false
+	}
+	q, want := resp.Questions[0], query.Questions[0]
+	return q.Type == want.Type && q.Class == want.Class && strings.EqualFold(q.Name.String(), want.Name.String())
+}
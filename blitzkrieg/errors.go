@@ -0,0 +1,124 @@
+package blitzkrieg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/gorilla/websocket"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Categories that transport errors are grouped into in reports. The raw
+// messages embed addresses and ports, so counting them as is would split
+// the same failure into as many lines as there are targets.
+const (
+	errConnectTimeout = "connect timeout"
+	errReadTimeout    = "read timeout"
+	errWriteTimeout   = "write timeout"
+	errTimeout        = "timeout"
+	errRefused        = "connection refused"
+	errReset          = "connection reset"
+	errBrokenPipe     = "broken pipe"
+	errUnreachable    = "unreachable"
+	errDNS            = "dns"
+	errTLS            = "tls"
+	errEOF            = "eof"
+	errFileLimit      = "too many open files"
+	errPortsExhausted = "ports exhausted"
+	errWSClosed       = "websocket closed"
+	errUnanswered     = "unanswered"
+	errProxy          = "proxy"
+	errNetDropped     = "emulated drop"
+	errOther          = "other"
+)
+
+// errPipelineClosed fails the pipelined requests that were still in flight
+// when the server closed the connection
+var errPipelineClosed = errors.New("connection closed with pipelined requests unanswered")
+
+// maxErrorExamples is the number of distinct raw messages kept per category
+const maxErrorExamples = 3
+
+// classifyError returns the category of a transport error
+func classifyError(err error) string {
+	var (
+		proxyErr   *proxyError
+		dnsErr     *net.DNSError
+		opErr      *net.OpError
+		netErr     net.Error
+		recordErr  tls.RecordHeaderError
+		alertErr   tls.AlertError
+		certErr    *tls.CertificateVerificationError
+		authErr    x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+		closeErr   *websocket.CloseError
+	)
+	switch {
+	case errors.Is(err, syscall.EMFILE), errors.Is(err, syscall.ENFILE):
+		return errFileLimit
+	case isPortExhausted(err):
+		return errPortsExhausted
+	case errors.Is(err, errNetDrop):
+		return errNetDropped
+	case errors.As(err, &proxyErr):
+		return errProxy
+	case errors.As(err, &dnsErr):
+		return errDNS
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &certErr),
+		errors.As(err, &authErr), errors.As(err, &hostErr), errors.As(err, &invalidErr),
+		strings.Contains(err.Error(), "tls: "):
+		return errTLS
+	case errors.As(err, &closeErr):
+		return errWSClosed
+	case errors.Is(err, errPipelineClosed):
+		return errUnanswered
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return errRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return errReset
+	case errors.Is(err, syscall.EPIPE):
+		return errBrokenPipe
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return errUnreachable
+	case errors.As(err, &opErr) && opErr.Timeout():
+		switch opErr.Op {
+		case "dial":
+			return errConnectTimeout
+		case "read":
+			return errReadTimeout
+		case "write":
+			return errWriteTimeout
+		}
+		return errTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return errTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), strings.HasSuffix(err.Error(), "EOF"):
+		return errEOF
+	}
+	return errOther
+}
+
+// An errorClass counts the errors of one category and keeps a few of the
+// raw messages as examples
+type errorClass struct {
+	count    int64
+	examples []string
+}
+
+func (ec *errorClass) add(err error) {
+	ec.count++
+	if len(ec.examples) == maxErrorExamples {
+		return
+	}
+	msg := err.Error()
+	for _, example := range ec.examples {
+		if example == msg {
+			return
+		}
+	}
+	ec.examples = append(ec.examples, msg)
+}
@@ -0,0 +1,86 @@
+package blitzkrieg
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+// How streamed response bodies are split into events
+const (
+	eventsSSE    = "sse"    // Server-Sent Events, ended by a blank line; comments are keep-alives, not events
+	eventsLines  = "lines"  // one event per non-empty line, as in NDJSON
+	eventsChunks = "chunks" // one event per read that returns data
+)
+
+// eventTimes is the timing of the events of a single response
+type eventTimes struct {
+	count int64
+	first time.Duration   // from the start of the request to the first event
+	gaps  []time.Duration // between consecutive events
+}
+
+// readEvents reads body as it arrives, timing the events mode splits it
+// into from start. It returns the whole body, like ioutil.ReadAll.
+func readEvents(body io.Reader, mode string, start time.Time) ([]byte, *eventTimes, error) {
+	var (
+		data      []byte
+		last      time.Time
+		lineStart int  // offset of the line being read
+		hasField  bool // whether the SSE event being read has a field
+	)
+	times := &eventTimes{}
+	event := func(now time.Time) {
+		if times.count == 0 {
+			times.first = now.Sub(start)
+		} else {
+			times.gaps = append(times.gaps, now.Sub(last))
+		}
+		times.count++
+		last = now
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := body.Read(buf)
+		now := time.Now()
+		if n > 0 {
+			scanned := len(data)
+			data = append(data, buf[:n]...)
+			if mode == eventsChunks {
+				event(now)
+			}
+			for mode != eventsChunks {
+				i := bytes.IndexByte(data[scanned:], '\n')
+				if i < 0 {
+					break
+				}
+				line := bytes.TrimSuffix(data[lineStart:scanned+i], []byte("\r"))
+				scanned += i + 1
+				lineStart = scanned
+				switch {
+				case mode == eventsLines:
+					if len(bytes.TrimSpace(line)) > 0 {
+						event(now)
+					}
+				case len(line) == 0:
+					if hasField {
+						event(now)
+					}
+					hasField = false
+				case line[0] != ':':
+					hasField = true
+				}
+			}
+		}
+		if err != nil {
+			// a last line without a newline is complete, a last SSE event is not
+			if mode == eventsLines && len(bytes.TrimSpace(data[lineStart:])) > 0 {
+				event(now)
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			return data, times, err
+		}
+	}
+}
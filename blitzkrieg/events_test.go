@@ -0,0 +1,87 @@
+package blitzkrieg
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pieceReader returns one piece per read, as a body arriving over the network,
+// then err
+type pieceReader struct {
+	pieces []string
+	err    error
+}
+
+func (r *pieceReader) Read(p []byte) (int, error) {
+	if len(r.pieces) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.pieces[0])
+	if n < len(r.pieces[0]) {
+		r.pieces[0] = r.pieces[0][n:]
+	} else {
+		r.pieces = r.pieces[1:]
+	}
+	return n, nil
+}
+
+func TestReadEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		pieces []string
+		count  int64
+	}{
+		{"sse", eventsSSE, []string{"data: a\n\ndata: b\n\n"}, 2},
+		{"sse split", eventsSSE, []string{"data: a\n", "\ndat", "a: b\nid: 2", "\n", "\n"}, 2},
+		{"sse crlf", eventsSSE, []string{"data: a\r\n\r\nevent: x\r\ndata: b\r\n\r", "\n"}, 2},
+		{"sse multi line", eventsSSE, []string{"data: a\ndata: b\ndata: c\n\n"}, 1},
+		{"sse keep-alive", eventsSSE, []string{": ping\n\n", ":\n\n", "data: a\n\n", ": ping\n\n"}, 1},
+		{"sse comment in event", eventsSSE, []string{": ping\ndata: a\n\n"}, 1},
+		{"sse unended", eventsSSE, []string{"data: a\n\ndata: b\n"}, 1},
+		{"sse blank lines", eventsSSE, []string{"\n\n\r\n"}, 0},
+		{"lines", eventsLines, []string{"{\"a\":1}\n{\"b\":2}\n"}, 2},
+		{"lines split", eventsLines, []string{"{\"a\"", ":1}\n{", "\"b\":2}", "\n"}, 2},
+		{"lines crlf", eventsLines, []string{"a\r\nb\r", "\n"}, 2},
+		{"lines blank", eventsLines, []string{"a\n\n  \r\n\nb\n"}, 2},
+		{"lines unended", eventsLines, []string{"a\nb"}, 2},
+		{"lines unended blank", eventsLines, []string{"a\n  "}, 1},
+		{"chunks", eventsChunks, []string{"a", "bc\n", "\n"}, 3},
+		{"empty", eventsSSE, nil, 0},
+	}
+	for _, test := range tests {
+		start := time.Now()
+		body, times, err := readEvents(&pieceReader{pieces: append([]string(nil), test.pieces...), err: io.EOF},
+			test.mode, start)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if want := strings.Join(test.pieces, ""); string(body) != want {
+			t.Errorf("%s: got body %q, want %q", test.name, body, want)
+		}
+		if times.count != test.count {
+			t.Errorf("%s: got %d events, want %d", test.name, times.count, test.count)
+		}
+		if gaps := int64(len(times.gaps)); test.count > 0 && gaps != test.count-1 {
+			t.Errorf("%s: got %d gaps for %d events", test.name, gaps, times.count)
+		}
+		if times.first < 0 || times.first > time.Since(start) {
+			t.Errorf("%s: got first event at %v", test.name, times.first)
+		}
+	}
+}
+
+func TestReadEventsError(t *testing.T) {
+	broken := errors.New("connection reset")
+	body, times, err := readEvents(&pieceReader{pieces: []string{"a\n", "b"}, err: broken}, eventsLines, time.Now())
+	if err != broken {
+		t.Errorf("got error %v, want %v", err, broken)
+	}
+	if string(body) != "a\nb" || times.count != 2 {
+		t.Errorf("got body %q and %d events, want what arrived", body, times.count)
+	}
+}
@@ -0,0 +1,489 @@
+package blitzkrieg
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io"
+	"io/ioutil"
+	"net"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// protoGRPC is the protocol of gRPC results
+const protoGRPC = "grpc"
+
+// isGRPC tells whether rawurl is a grpc:// (plaintext) or grpcs:// (TLS) URL.
+// The path of the URL is the full method name: /package.Service/Method.
+func isGRPC(rawurl string) bool {
+	return strings.HasPrefix(rawurl, "grpc://") || strings.HasPrefix(rawurl, "grpcs://")
+}
+
+// grpcCredentials returns the transport credentials for a gRPC URL, with
+// cfg for grpcs. The handshakes are counted in stats unless it is nil.
+func grpcCredentials(u *neturl.URL, cfg *tls.Config, stats *tlsStats, conn *grpcConn) grpc.DialOption {
+	if u.Scheme == "grpcs" {
+		creds := credentials.NewTLS(cfg)
+		if stats != nil {
+			creds = countedCredentials{TransportCredentials: creds, stats: stats, conn: conn}
+		}
+		return grpc.WithTransportCredentials(creds)
+	}
+	return grpc.WithTransportCredentials(insecure.NewCredentials())
+}
+
+// countedCredentials are TLS credentials that count their handshakes in the
+// TLS stats, and report them to the connection they are made for
+type countedCredentials struct {
+	credentials.TransportCredentials
+	stats *tlsStats
+	conn  *grpcConn
+}
+
+func (c countedCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	start := time.Now()
+	tlsConn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, conn)
+	var state tls.ConnectionState
+	if tlsInfo, ok := info.(credentials.TLSInfo); ok {
+		state = tlsInfo.State
+	}
+	c.stats.record(state, time.Since(start), err)
+	if c.conn != nil {
+		tlsConn = c.conn.dialed(tlsConn, err)
+	}
+	return tlsConn, info, err
+}
+
+func (c countedCredentials) Clone() credentials.TransportCredentials {
+	return countedCredentials{TransportCredentials: c.TransportCredentials.Clone(), stats: c.stats, conn: c.conn}
+}
+
+// resolveGRPC looks up the method descriptor of every gRPC request, in the
+// protoset file when there is one and through server reflection otherwise,
+// over connections of dialer
+func resolveGRPC(requests []*blitzRequest, protoset string, dialer *net.Dialer, timeout time.Duration) error {
+	var files *protoregistry.Files
+	if protoset != "" {
+		data, err := ioutil.ReadFile(protoset)
+		if err != nil {
+			return err
+		}
+		set := &descriptorpb.FileDescriptorSet{}
+		if err = protobuf.Unmarshal(data, set); err != nil {
+			return fmt.Errorf("%s: %s", protoset, err)
+		}
+		if files, err = protodesc.NewFiles(set); err != nil {
+			return fmt.Errorf("%s: %s", protoset, err)
+		}
+	}
+	reflected := make(map[string]*protoregistry.Files) // by host and service
+	for _, req := range requests {
+		if !isGRPC(req.url) {
+			continue
+		}
+		u, err := neturl.Parse(req.url)
+		if err != nil {
+			return err
+		}
+		path := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(path) != 2 || path[0] == "" || path[1] == "" {
+			return fmt.Errorf("%s does not name a method as /package.Service/Method", req.url)
+		}
+		reqFiles := files
+		if reqFiles == nil {
+			key := u.Scheme + "://" + u.Host + "/" + path[0]
+			if reqFiles = reflected[key]; reqFiles == nil {
+				if reqFiles, err = reflectFiles(u, path[0], dialer, timeout); err != nil {
+					return fmt.Errorf("reflection on %s: %s", u.Host, err)
+				}
+				reflected[key] = reqFiles
+			}
+		}
+		desc, err := reqFiles.FindDescriptorByName(protoreflect.FullName(path[0] + "." + path[1]))
+		if err != nil {
+			return fmt.Errorf("%s: %s", req.url, err)
+		}
+		method, ok := desc.(protoreflect.MethodDescriptor)
+		if !ok {
+			return fmt.Errorf("%s: %s is not a method", req.url, desc.FullName())
+		}
+		req.rpc = method
+	}
+	return nil
+}
+
+// reflectFiles fetches the file that defines service from the server
+// reflection of u, along with everything it imports
+func reflectFiles(u *neturl.URL, service string, dialer *net.Dialer, timeout time.Duration) (*protoregistry.Files, error) {
+	conn, err := grpc.NewClient("passthrough:///"+u.Host, grpcCredentials(u, targetTLS, nil, nil),
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			conn, _, err := dialTarget(ctx, dialer, "tcp", address)
+			return conn, err
+		}))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched := make(map[string]*descriptorpb.FileDescriptorProto)
+	ask := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return status.Error(codes.Code(errResp.ErrorCode), errResp.ErrorMessage)
+		}
+		for _, data := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := protobuf.Unmarshal(data, fd); err != nil {
+				return err
+			}
+			fetched[fd.GetName()] = fd
+		}
+		return nil
+	}
+	err = ask(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	})
+	// the server may leave out imports it thinks the client already has
+	for missing := true; err == nil && missing; {
+		missing = false
+		for _, fd := range fetched {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := fetched[dep]; !ok && err == nil {
+					missing = true
+					err = ask(&rpb.ServerReflectionRequest{
+						MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+					})
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fetched {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
+
+// grpcMessages parses the messages of a call to method from their JSON
+// templates. Unary and server streaming calls send one message, taken in
+// turn by seq; client and bidi streaming calls send them all.
+func grpcMessages(method protoreflect.MethodDescriptor, templates []string, client int64, seq int64) ([]protobuf.Message, error) {
+	if len(templates) == 0 {
+		templates = []string{"{}"}
+	}
+	if !method.IsStreamingClient() {
+		templates = templates[seq%int64(len(templates)) : seq%int64(len(templates))+1]
+	}
+	msgs := make([]protobuf.Message, len(templates))
+	for i, template := range templates {
+		msg := dynamicpb.NewMessage(method.Input())
+		if err := protojson.Unmarshal([]byte(renderMessage(template, client, seq)), msg); err != nil {
+			return nil, fmt.Errorf("message for %s: %s", method.FullName(), err)
+		}
+		msgs[i] = msg
+	}
+	return msgs, nil
+}
+
+// grpcurl returns a grpcurl command line reproducing the call of req
+func grpcurl(req *blitzRequest) string {
+	u, _ := neturl.Parse(req.url)
+	cmd := []string{"grpcurl", "-insecure"}
+	if u.Scheme == "grpc" {
+		cmd[1] = "-plaintext"
+	}
+	for _, h := range harHeaders(req.header) {
+		cmd = append(cmd, "-H", shellQuote(h.Name+": "+h.Value))
+	}
+	if req.body != "" {
+		cmd = append(cmd, "-d", shellQuote(req.body))
+	}
+	return strings.Join(append(cmd, u.Host, strings.TrimPrefix(u.Path, "/")), " ")
+}
+
+// A grpcClient is the gRPC side of a connection slot of a simulated client.
+// It keeps one gRPC connection per host, which the streams of the slot
+// multiplex their calls over.
+type grpcClient struct {
+	sync.Mutex
+	blitz    *Blitz
+	id       int64
+	conns    map[string]*grpcConn
+	seq      int64
+	inflight int32 // calls in flight on the connections
+	tls      *tls.Config
+}
+
+func (blitz *Blitz) newGRPCClient() *grpcClient {
+	return &grpcClient{
+		blitz: blitz,
+		id:    atomic.AddInt64(&blitz.grpcClients, 1),
+		conns: make(map[string]*grpcConn),
+		tls:   clientTLS(),
+	}
+}
+
+// A grpcSlot holds the gRPC client of one of the connection slots of a
+// raider, created by the first gRPC request of its streams so that tests
+// without any do not set up clients
+type grpcSlot struct {
+	once sync.Once
+	gc   *grpcClient
+}
+
+func (gs *grpcSlot) client(blitz *Blitz) *grpcClient {
+	gs.once.Do(func() { gs.gc = blitz.newGRPCClient() })
+	return gs.gc
+}
+
+// A grpcConn is a gRPC connection that keeps track of the transport
+// connections it dials in the background, so that the calls can tell the
+// new ones and the reason they could not connect
+type grpcConn struct {
+	*grpc.ClientConn
+	sync.Mutex
+	dials int64 // transport connections established
+	err   error // failure of the last transport connection, nil while one is up
+}
+
+// dialed records a connection attempt, with TLS once its handshake is done,
+// and returns netConn reporting its read failures
+func (conn *grpcConn) dialed(netConn net.Conn, err error) net.Conn {
+	conn.failed(err)
+	if err != nil {
+		return nil
+	}
+	conn.Lock()
+	conn.dials++
+	conn.Unlock()
+	return &grpcNetConn{Conn: netConn, grpc: conn}
+}
+
+// failed records the failure of the transport connection
+func (conn *grpcConn) failed(err error) {
+	conn.Lock()
+	conn.err = err
+	conn.Unlock()
+}
+
+// state returns the number of connections established and the failure of
+// the last attempt
+func (conn *grpcConn) state() (int64, error) {
+	conn.Lock()
+	defer conn.Unlock()
+	return conn.dials, conn.err
+}
+
+// A grpcNetConn is a transport connection of a grpcConn. Its read failures,
+// like the TLS alerts of servers rejecting the client certificate after the
+// handshake, are the reasons the calls could not connect. Only the first one
+// counts, the reads that follow fail on the connection gRPC closed.
+type grpcNetConn struct {
+	net.Conn
+	grpc   *grpcConn
+	failed int32
+}
+
+func (c *grpcNetConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil && atomic.CompareAndSwapInt32(&c.failed, 0, 1) {
+		c.grpc.failed(err)
+	}
+	return n, err
+}
+
+// conn returns the connection to the host of req. It dials through
+// blitz.dial so that its transport connections are counted like the HTTP
+// ones, when a call needs one.
+func (gc *grpcClient) conn(req *blitzRequest) (*grpcConn, error) {
+	u, err := neturl.Parse(req.url)
+	if err != nil {
+		return nil, err
+	}
+	gc.Lock()
+	defer gc.Unlock()
+	key := u.Scheme + "://" + u.Host
+	if conn := gc.conns[key]; conn != nil {
+		return conn, nil
+	}
+	conn := &grpcConn{}
+	conn.ClientConn, err = grpc.NewClient("passthrough:///"+u.Host, grpcCredentials(u, gc.tls, gc.blitz.tls, conn),
+		grpc.WithUserAgent("blitz "+VERSION),
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			netConn, err := gc.blitz.dial(ctx, "tcp", address)
+			if err != nil || u.Scheme != "grpcs" {
+				// the credentials report the connections once TLS is up
+				netConn = conn.dialed(netConn, err)
+			}
+			return netConn, err
+		}))
+	if err != nil {
+		return nil, err
+	}
+	gc.conns[key] = conn
+	return conn, nil
+}
+
+// drop closes the connection to the host of req, so that the next call dials
+// again
+func (gc *grpcClient) drop(req *blitzRequest) {
+	u, _ := neturl.Parse(req.url)
+	gc.Lock()
+	defer gc.Unlock()
+	key := u.Scheme + "://" + u.Host
+	if conn := gc.conns[key]; conn != nil {
+		conn.Close()
+		delete(gc.conns, key)
+	}
+}
+
+// call makes a call to the method of req and reports its status code
+func (gc *grpcClient) call(req *blitzRequest) {
+	blitz := gc.blitz
+	templates := blitz.messages
+	if req.body != "" {
+		templates = []string{req.body}
+	}
+	msgs, err := grpcMessages(req.rpc, templates, gc.id, atomic.AddInt64(&gc.seq, 1)-1)
+	md := metadata.MD{}
+	for key, values := range req.header {
+		switch key {
+		case "User-Agent", "Content-Type", "Content-Length":
+			continue
+		}
+		md.Append(key, values...)
+	}
+
+	s := time.Now()
+	var (
+		size    int64
+		fresh   bool
+		streams int32
+		connErr error // the reason the call could not connect
+	)
+	if err == nil {
+		var conn *grpcConn
+		if conn, connErr = gc.conn(req); connErr == nil {
+			dials, _ := conn.state()
+			streams = atomic.AddInt32(&gc.inflight, 1)
+			size, err = gc.invoke(conn.ClientConn, req.rpc, msgs, md)
+			atomic.AddInt32(&gc.inflight, -1)
+			var after int64
+			after, connErr = conn.state()
+			fresh = after > dials
+			if status.Code(err) != codes.Unavailable {
+				// the server answered, whatever became of other attempts
+				connErr = nil
+			}
+		}
+	}
+	end := time.Now()
+	code := status.Code(err)
+	if connErr != nil {
+		code = codes.Unavailable
+	}
+	result := &blitzResult{
+		name:       req.name,
+		statusCode: int(code),
+		duration:   end.Sub(s),
+		newConn:    fresh,
+		reused:     streams > 0 && !fresh && connErr == nil,
+		proto:      protoGRPC,
+		streams:    streams,
+		success:    code == codes.OK,
+		bodySize:   size,
+		headerOut:  int64(headerSize(req.header)),
+		timestamp:  end,
+	}
+	if connErr != nil {
+		// the call could not connect, it fails like the HTTP requests that
+		// do not
+		result.err, result.errClass = connErr, classifyError(connErr)
+	} else if _, ok := status.FromError(err); !ok {
+		// the message could not be built, the call was never made
+		result.err, result.errClass = err, errOther
+	} else if result.success {
+		result.contentLength = size
+	}
+	if connErr != nil && blitz.samples != nil {
+		blitz.samples.capture(req, s, end.Sub(s), nil, nil, connErr, result.errClass, nil)
+	} else if !result.success && blitz.samples != nil {
+		blitz.samples.capture(req, s, end.Sub(s), nil, nil, err, "grpc "+code.String(), nil)
+	}
+	if !blitz.keepAlive {
+		gc.drop(req)
+	}
+	blitz.results <- result
+}
+
+// invoke sends msgs over a stream of its own and reads the replies until the
+// server closes it, returning their total size. Unary calls are streams that
+// carry a single message each way.
+func (gc *grpcClient) invoke(conn *grpc.ClientConn, method protoreflect.MethodDescriptor, msgs []protobuf.Message, md metadata.MD) (int64, error) {
+	timeout := time.Duration(gc.blitz.connectTimeout+gc.blitz.readTimeout) * time.Millisecond
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), timeout)
+	defer cancel()
+	desc := &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ServerStreams: method.IsStreamingServer(),
+		ClientStreams: method.IsStreamingClient(),
+	}
+	stream, err := conn.NewStream(ctx, desc, "/"+string(method.Parent().FullName())+"/"+string(method.Name()))
+	if err != nil {
+		return 0, err
+	}
+	for _, msg := range msgs {
+		// a failed send leaves the status of the call to the receiving side
+		if err = stream.SendMsg(msg); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		stream.CloseSend()
+	}
+	var size int64
+	for {
+		reply := dynamicpb.NewMessage(method.Output())
+		if err = stream.RecvMsg(reply); err != nil {
+			break
+		}
+		size += int64(protobuf.Size(reply))
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return size, err
+}
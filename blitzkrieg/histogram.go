@@ -0,0 +1,125 @@
+package blitzkrieg
+
+import (
+	"math/bits"
+	"time"
+)
+
+// Latencies are counted in log-linear bins of microseconds: values below
+// histSub get a bin each, above that every power of two is split into
+// histSub bins. This keeps the relative error under 1/histSub while the
+// histogram has a fixed size no matter how many values it holds.
+const (
+	histSubBits = 5
+	histSub     = 1 << histSubBits
+	histMaxBits = 36 // 2^36µs is a little over 19 hours
+	histBins    = (histMaxBits - histSubBits + 1) * histSub
+)
+
+// A histogram records durations with bounded memory
+type histogram struct {
+	counts [histBins]uint64
+	count  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func newHistogram() *histogram {
+	return &histogram{}
+}
+
+// histIndex returns the bin for v microseconds
+func histIndex(v int64) int {
+	if v < histSub {
+		if v < 0 {
+			return 0
+		}
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - histSubBits - 1
+	i := (shift+1)*histSub + int(v>>uint(shift)) - histSub
+	if i >= histBins {
+		return histBins - 1
+	}
+	return i
+}
+
+// histValue returns the mid point of bin i in microseconds
+func histValue(i int) int64 {
+	if i < histSub {
+		return int64(i)
+	}
+	shift := uint(i/histSub - 1)
+	lower := int64(i%histSub+histSub) << shift
+	return lower + (int64(1)<<shift)/2
+}
+
+// record adds a duration to the histogram. A nil histogram, one the bucket
+// does not keep, drops it.
+func (h *histogram) record(d time.Duration) {
+	if h == nil {
+		return
+	}
+	h.counts[histIndex(int64(d/time.Microsecond))]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+}
+
+// merge adds all the values recorded in o to h
+func (h *histogram) merge(o *histogram) {
+	if o.count == 0 {
+		return
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+	h.count += o.count
+	h.sum += o.sum
+}
+
+// quantile returns the value below which q (0..1) of the recorded values fall
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(h.count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			d := time.Duration(histValue(i)) * time.Microsecond
+			if d > h.max {
+				return h.max
+			}
+			if d < h.min {
+				return h.min
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+// mean returns the average of the recorded values
+func (h *histogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
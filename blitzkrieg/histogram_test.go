@@ -0,0 +1,121 @@
+package blitzkrieg
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHistBins(t *testing.T) {
+	tests := []struct {
+		v     int64 // microseconds
+		index int
+		value int64 // mid point of the bin
+	}{
+		{-5, 0, 0},
+		{0, 0, 0},
+		{1, 1, 1},
+		{31, 31, 31},
+		{32, 32, 32},
+		{33, 33, 33},
+		{63, 63, 63},
+		{64, 64, 65},
+		{65, 64, 65},
+		{66, 65, 67},
+		{127, 95, 127},
+		{128, 96, 130},
+		{1 << 35, 992, 1<<35 + 1<<29},
+		{1<<36 - 1, histBins - 1, 63<<30 + 1<<29},
+		{1 << 36, histBins - 1, 63<<30 + 1<<29},
+		{1 << 40, histBins - 1, 63<<30 + 1<<29},
+	}
+	for _, test := range tests {
+		i := histIndex(test.v)
+		if i != test.index {
+			t.Errorf("histIndex(%d) = %d, want %d", test.v, i, test.index)
+			continue
+		}
+		if v := histValue(i); v != test.value {
+			t.Errorf("histValue(%d) = %d, want %d", i, v, test.value)
+		}
+	}
+	// every bin takes the values up to the next one
+	for i := 1; i < histBins; i++ {
+		lower := histValue(i-1) + 1
+		if i > histSub {
+			lower = int64(i%histSub+histSub) << uint(i/histSub-1)
+		}
+		if histIndex(lower) != i || histIndex(lower-1) != i-1 {
+			t.Fatalf("bin %d does not start at %d", i, lower)
+		}
+	}
+}
+
+func TestHistQuantile(t *testing.T) {
+	tests := []struct {
+		name   string
+		values func(r *rand.Rand) int64 // microseconds
+	}{
+		{"small", func(r *rand.Rand) int64 { return r.Int63n(100) }},
+		{"uniform", func(r *rand.Rand) int64 { return r.Int63n(1000000) }},
+		{"exponential", func(r *rand.Rand) int64 { return int64(r.ExpFloat64() * 20000) }},
+		{"spread", func(r *rand.Rand) int64 { return r.Int63n(1 << uint(r.Intn(histMaxBits))) }},
+	}
+	for _, test := range tests {
+		r := rand.New(rand.NewSource(1))
+		h := newHistogram()
+		var values []time.Duration
+		for i := 0; i < 10000; i++ {
+			d := time.Duration(test.values(r)) * time.Microsecond
+			values = append(values, d)
+			h.record(d)
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		if h.min != values[0] || h.max != values[len(values)-1] {
+			t.Errorf("%s: got min %v, max %v, want %v and %v", test.name, h.min, h.max, values[0], values[len(values)-1])
+		}
+		for _, q := range []float64{0, 0.1, 0.5, 0.9, 0.99, 0.999, 1} {
+			rank := int(q*float64(len(values)) + 0.5)
+			if rank < 1 {
+				rank = 1
+			}
+			want := values[rank-1]
+			got := h.quantile(q)
+			if diff := got - want; diff*histSub > want || -diff*histSub > want {
+				t.Errorf("%s: quantile(%v) = %v, want %v within 1/%d", test.name, q, got, want, histSub)
+			}
+		}
+	}
+}
+
+func TestHistMerge(t *testing.T) {
+	whole, a, b := newHistogram(), newHistogram(), newHistogram()
+	for i := 0; i < 1000; i++ {
+		d := time.Duration(i*i) * time.Microsecond
+		whole.record(d)
+		if i%3 == 0 {
+			a.record(d)
+		} else {
+			b.record(d)
+		}
+	}
+	merged := newHistogram()
+	merged.merge(newHistogram())
+	merged.merge(a)
+	merged.merge(b)
+	if *merged != *whole {
+		t.Errorf("got count %d, sum %v, min %v, max %v, want %d, %v, %v and %v", merged.count, merged.sum,
+			merged.min, merged.max, whole.count, whole.sum, whole.min, whole.max)
+	}
+	if merged.quantile(0.5) != whole.quantile(0.5) || merged.mean() != whole.mean() {
+		t.Errorf("merged histogram differs from the one recording every value")
+	}
+
+	var empty histogram
+	if empty.quantile(0.5) != 0 || empty.mean() != 0 {
+		t.Errorf("empty histogram has quantile %v, mean %v, want 0", empty.quantile(0.5), empty.mean())
+	}
+	var dropped *histogram
+	dropped.record(time.Second)
+}
@@ -0,0 +1,361 @@
+package blitzkrieg
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// jsonLatency holds latencies in seconds
+type jsonLatency struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90,omitempty"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type jsonName struct {
+	Name          string                `json:"name"`
+	Requests      int64                 `json:"requests"`
+	Success       int64                 `json:"success"`
+	NetworkErrors int64                 `json:"networkErrors"`
+	StatusCodes   map[string]int64      `json:"statusCodes"`
+	Errors        map[string]*jsonError `json:"errors"`
+	CheckFailures int64                 `json:"checkFailures"`
+	Latency       jsonLatency           `json:"latency"`
+	Rate          float64               `json:"rate"`
+}
+
+type jsonError struct {
+	Count    int64    `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+type jsonInterval struct {
+	Elapsed  float64     `json:"elapsed"`
+	Requests int64       `json:"requests"`
+	Success  int64       `json:"success"`
+	Errors   int64       `json:"errors"`
+	Rate     float64     `json:"rate"`
+	Bytes    int64       `json:"bytes"`
+	Latency  jsonLatency `json:"latency"`
+
+	OpenConns    int64 `json:"openConns"`
+	NewConns     int64 `json:"newConns"`
+	ReusedConns  int64 `json:"reusedConns"`
+	ServerClosed int64 `json:"serverClosed"`
+	WireIn       int64 `json:"wireIn"`
+	WireOut      int64 `json:"wireOut"`
+
+	Events *jsonIntervalEvents `json:"events,omitempty"`
+}
+
+// jsonIntervalEvents holds the event timings of an interval in seconds
+type jsonIntervalEvents struct {
+	Count         int64   `json:"count"`
+	FirstEventP50 float64 `json:"firstEventP50"`
+	FirstEventP99 float64 `json:"firstEventP99"`
+	GapP50        float64 `json:"gapP50"`
+	GapP99        float64 `json:"gapP99"`
+}
+
+type jsonEvents struct {
+	Responses  int64        `json:"responses"`
+	Count      int64        `json:"count"`
+	Mean       float64      `json:"mean"` // events per response
+	Max        int64        `json:"max"`
+	FirstEvent *jsonLatency `json:"firstEvent"`
+	Gap        *jsonLatency `json:"gap"`
+	Stream     *jsonLatency `json:"stream"`
+}
+
+type jsonWire struct {
+	In          int64   `json:"in"`
+	Out         int64   `json:"out"`
+	InRate      float64 `json:"inRate"`  // bytes per second
+	OutRate     float64 `json:"outRate"` // bytes per second
+	Compression float64 `json:"compression,omitempty"`
+	HeaderIn    float64 `json:"headerIn"`
+	HeaderOut   float64 `json:"headerOut"`
+	RequestIn   float64 `json:"requestIn"`  // average bytes per request, over http1.1
+	RequestOut  float64 `json:"requestOut"` // average bytes per request, over http1.1
+}
+
+type jsonConns struct {
+	New           int64        `json:"new"`
+	Reused        int64        `json:"reused"`
+	ServerClosed  int64        `json:"serverClosed"`
+	Stale         int64        `json:"stale"`
+	NewLatency    *jsonLatency `json:"newLatency"`
+	ReusedLatency *jsonLatency `json:"reusedLatency"`
+	StreamsMean   float64      `json:"streamsMean"`
+	StreamsMax    int32        `json:"streamsMax"`
+}
+
+type jsonQUIC struct {
+	Handshakes int64        `json:"handshakes"`
+	Resumed    int64        `json:"resumed"`
+	ZeroRTT    int64        `json:"zeroRTT"`
+	Failed     int64        `json:"failed"`
+	Latency    *jsonLatency `json:"latency"`
+}
+
+type jsonTLS struct {
+	Full           int64            `json:"full"`
+	Resumed        int64            `json:"resumed"`
+	Failed         int64            `json:"failed"`
+	FullLatency    *jsonLatency     `json:"fullLatency"`
+	ResumedLatency *jsonLatency     `json:"resumedLatency"`
+	Versions       map[string]int64 `json:"versions"`
+	Ciphers        map[string]int64 `json:"ciphers"`
+	ALPN           map[string]int64 `json:"alpn"`
+	Ticketless     int64            `json:"ticketless,omitempty"`
+}
+
+type jsonWebSockets struct {
+	Upgrades int64 `json:"upgrades"`
+	Drops    int64 `json:"drops"`
+	Open     int64 `json:"open"`
+	MaxOpen  int64 `json:"maxOpen"`
+}
+
+// jsonReport is the layout of the report written with -o json
+type jsonReport struct {
+	Requests      int64                   `json:"requests"`
+	Success       int64                   `json:"success"`
+	Availability  float64                 `json:"availability"`
+	NetworkErrors int64                   `json:"networkErrors"`
+	StatusCodes   map[string]int64        `json:"statusCodes"`
+	Errors        map[string]*jsonError   `json:"errors"`
+	CheckFailures int64                   `json:"checkFailures"`
+	Checks        map[string]int64        `json:"checks"`
+	Latency       jsonLatency             `json:"latency"`
+	Rate          float64                 `json:"rate"`
+	Bytes         int64                   `json:"bytes"`
+	Duration      float64                 `json:"duration"`
+	Names         []*jsonName             `json:"names"`
+	Interval      float64                 `json:"interval"`
+	Series        []*jsonInterval         `json:"series"`
+	Phases        map[string]*jsonLatency `json:"phases"`
+	Connections   *jsonConns              `json:"connections"`
+	Protocols     map[string]int64        `json:"protocols"`
+	QUIC          *jsonQUIC               `json:"quic,omitempty"`
+	TLS           *jsonTLS                `json:"tls,omitempty"`
+	Addresses     map[string]int64        `json:"addresses,omitempty"`
+	WebSockets    *jsonWebSockets         `json:"webSockets,omitempty"`
+	Events        *jsonEvents             `json:"events,omitempty"`
+	Wire          *jsonWire               `json:"wire"`
+	Thresholds    []*jsonThreshold        `json:"thresholds"`
+	Aborted       string                  `json:"aborted,omitempty"`
+	Samples       string                  `json:"samples,omitempty"`
+}
+
+type jsonThreshold struct {
+	Threshold string  `json:"threshold"`
+	Value     float64 `json:"value"`
+	Passed    bool    `json:"passed"`
+}
+
+func newJSONLatency(phase *phaseReport) *jsonLatency {
+	return &jsonLatency{
+		Mean: phase.avgLat,
+		P50:  phase.p50Lat,
+		P90:  phase.p90Lat,
+		P99:  phase.p99Lat,
+		Max:  phase.maxLat,
+	}
+}
+
+// jsonPhases keys the phases that happened at least once by name
+func jsonPhases(phases [numPhases]*phaseReport) map[string]*jsonLatency {
+	out := make(map[string]*jsonLatency)
+	for i, phase := range phases {
+		if phase.count > 0 {
+			out[phaseNames[i]] = newJSONLatency(phase)
+		}
+	}
+	return out
+}
+
+func jsonErrors(errors map[string]*errorClass) map[string]*jsonError {
+	out := make(map[string]*jsonError, len(errors))
+	for category, class := range errors {
+		out[category] = &jsonError{Count: class.count, Examples: class.examples}
+	}
+	return out
+}
+
+func jsonCodes(codes map[int]int64) map[string]int64 {
+	out := make(map[string]int64, len(codes))
+	for code, count := range codes {
+		out[statusText(code)] = count
+	}
+	return out
+}
+
+// writeJSON writes the report to a json file named after the current time
+func writeJSON(report *report) {
+	out := &jsonReport{
+		Requests:      report.totalRequests,
+		Success:       report.totalSuccess,
+		NetworkErrors: report.totalHttpErrors,
+		StatusCodes:   jsonCodes(report.statusCodes),
+		Errors:        jsonErrors(report.errors),
+		CheckFailures: report.checkFailures,
+		Checks:        report.checks,
+		Latency: jsonLatency{
+			Mean: report.avgLat,
+			P50:  report.percentile50Lat,
+			P99:  report.percentile99Lat,
+			Max:  report.maxLat,
+		},
+		Rate:     float64(report.totalSuccess) / report.totalTime,
+		Bytes:    report.totalSize,
+		Duration: report.totalTime,
+		Names:    make([]*jsonName, 0, len(report.names)),
+		Interval: report.interval,
+		Series:   make([]*jsonInterval, 0, len(report.series)),
+		Phases:   jsonPhases(report.phases),
+		Connections: &jsonConns{
+			New:           report.newConns,
+			Reused:        report.reusedConns,
+			ServerClosed:  report.serverClosed,
+			Stale:         report.staleErrors,
+			NewLatency:    newJSONLatency(report.newConnLat),
+			ReusedLatency: newJSONLatency(report.reusedConnLat),
+			StreamsMean:   report.streamsMean,
+			StreamsMax:    report.streamsMax,
+		},
+		Protocols: report.protocols,
+		Wire: &jsonWire{
+			In:          report.wireIn,
+			Out:         report.wireOut,
+			InRate:      float64(report.wireIn) / report.totalTime,
+			OutRate:     float64(report.wireOut) / report.totalTime,
+			Compression: report.compression,
+			HeaderIn:    report.headerIn,
+			HeaderOut:   report.headerOut,
+			RequestIn:   report.requestIn,
+			RequestOut:  report.requestOut,
+		},
+	}
+	if report.totalRequests > 0 {
+		out.Availability = float64(report.totalSuccess) * 100 / float64(report.totalRequests)
+	}
+	for _, name := range report.names {
+		out.Names = append(out.Names, &jsonName{
+			Name:          name.name,
+			Requests:      name.requests,
+			Success:       name.success,
+			NetworkErrors: name.netErrors,
+			StatusCodes:   jsonCodes(name.statusCodes),
+			Errors:        jsonErrors(name.errors),
+			CheckFailures: name.checkFails,
+			Latency: jsonLatency{
+				Mean: name.avgLat,
+				P50:  name.p50Lat,
+				P90:  name.p90Lat,
+				P99:  name.p99Lat,
+				Max:  name.maxLat,
+			},
+			Rate: name.rate,
+		})
+	}
+	out.Thresholds = make([]*jsonThreshold, 0, len(report.thresholds))
+	for _, result := range report.thresholds {
+		out.Thresholds = append(out.Thresholds, &jsonThreshold{
+			Threshold: result.threshold.expr,
+			Value:     result.value,
+			Passed:    result.passed,
+		})
+	}
+	out.Aborted = report.aborted
+	if ws := report.ws; ws.upgrades > 0 {
+		out.WebSockets = &jsonWebSockets{Upgrades: ws.upgrades, Drops: ws.drops, Open: ws.open, MaxOpen: ws.maxOpen}
+	}
+	if q := report.quic; q != nil {
+		out.QUIC = &jsonQUIC{
+			Handshakes: q.handshakes.count,
+			Resumed:    q.resumed,
+			ZeroRTT:    q.used0RTT,
+			Failed:     q.failed,
+			Latency:    newJSONLatency(q.handshakes),
+		}
+	}
+	if t := report.tls; t != nil {
+		out.TLS = &jsonTLS{
+			Full:           t.full.count,
+			Resumed:        t.resumed.count,
+			Failed:         t.failed,
+			FullLatency:    newJSONLatency(t.full),
+			ResumedLatency: newJSONLatency(t.resumed),
+			Versions:       t.versions,
+			Ciphers:        t.ciphers,
+			ALPN:           t.alpn,
+			Ticketless:     t.noTicket,
+		}
+	}
+	out.Addresses = report.addresses
+	if ev := report.events; ev != nil {
+		out.Events = &jsonEvents{
+			Responses:  ev.responses,
+			Count:      ev.count,
+			Mean:       ev.mean,
+			Max:        ev.max,
+			FirstEvent: newJSONLatency(ev.first),
+			Gap:        newJSONLatency(ev.gap),
+			Stream:     newJSONLatency(ev.stream),
+		}
+	}
+	out.Samples = report.samplesFile
+	for _, stat := range report.series {
+		interval := &jsonInterval{
+			Elapsed:  stat.elapsed,
+			Requests: stat.requests,
+			Success:  stat.success,
+			Errors:   stat.requests - stat.success,
+			Rate:     stat.rate,
+			Bytes:    stat.bytes,
+			Latency: jsonLatency{
+				Mean: stat.mean,
+				P50:  stat.p50,
+				P90:  stat.p90,
+				P99:  stat.p99,
+				Max:  stat.max,
+			},
+			OpenConns:    stat.openConns,
+			NewConns:     stat.newConns,
+			ReusedConns:  stat.reusedConns,
+			ServerClosed: stat.serverClosed,
+			WireIn:       stat.wireIn,
+			WireOut:      stat.wireOut,
+		}
+		if report.events != nil {
+			interval.Events = &jsonIntervalEvents{
+				Count:         stat.events,
+				FirstEventP50: stat.firstEventP50,
+				FirstEventP99: stat.firstEventP99,
+				GapP50:        stat.eventGapP50,
+				GapP99:        stat.eventGapP99,
+			}
+		}
+		out.Series = append(out.Series, interval)
+	}
+
+	fileName := time.Now().Format("2006-01-02-15-04-05.json")
+	fo, err := os.Create(fileName)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := fo.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	encoder := json.NewEncoder(fo)
+	if err := encoder.Encode(out); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,184 @@
+package blitzkrieg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// netConditions are the network conditions emulated on the connections of
+// the simulated clients, so that the servers see slow clients
+type netConditions struct {
+	latency time.Duration // added to every round trip
+	jitter  time.Duration // the latency varies by up to this much either way
+	up      int64         // bytes per second sent, 0 for no limit
+	down    int64         // bytes per second received, 0 for no limit
+	drop    float64       // chance that a connection drops, per second it is open
+}
+
+// netProfiles names the conditions -net takes. -latency, -jitter, -up, -down
+// and -drop override their values.
+var netProfiles = map[string]netConditions{
+	"2g":        {latency: 650 * time.Millisecond, jitter: 150 * time.Millisecond, up: 50000 / 8, down: 250000 / 8},
+	"3g":        {latency: 300 * time.Millisecond, jitter: 100 * time.Millisecond, up: 330000 / 8, down: 780000 / 8},
+	"4g":        {latency: 80 * time.Millisecond, jitter: 20 * time.Millisecond, up: 3000000 / 8, down: 9000000 / 8},
+	"slow-wifi": {latency: 50 * time.Millisecond, jitter: 40 * time.Millisecond, up: 500000 / 8, down: 1000000 / 8, drop: 0.001},
+}
+
+// errNetDrop fails the reads and writes of a connection the emulated network
+// dropped
+var errNetDrop = errors.New("connection dropped by the emulated network")
+
+// emulated is the emulated network, nil for none
+var emulated *netConditions
+
+// loadNetem sets the emulated network from -net and the options refining it,
+// the ones left at -1 keeping the values of the profile
+func loadNetem() error {
+	if netProfile == "" && netLatency < 0 && netJitter < 0 && netUp < 0 && netDown < 0 && netDrop < 0 {
+		return nil
+	}
+	var cond netConditions
+	if netProfile != "" {
+		profile, ok := netProfiles[netProfile]
+		if !ok {
+			var names []string
+			for name := range netProfiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown network profile %s, one of %s", netProfile, strings.Join(names, ", "))
+		}
+		cond = profile
+	}
+	if netLatency >= 0 {
+		cond.latency = time.Duration(netLatency) * time.Millisecond
+	}
+	if netJitter >= 0 {
+		cond.jitter = time.Duration(netJitter) * time.Millisecond
+	}
+	if netUp >= 0 {
+		cond.up = int64(netUp) * 1000 / 8
+	}
+	if netDown >= 0 {
+		cond.down = int64(netDown) * 1000 / 8
+	}
+	if netDrop >= 0 {
+		if netDrop >= 100 {
+			return fmt.Errorf("drops are a percentage below 100")
+		}
+		cond.drop = netDrop / 100
+	}
+	emulated = &cond
+	return nil
+}
+
+// delay returns the latency of a round trip, jittered
+func (nc *netConditions) delay() time.Duration {
+	d := nc.latency
+	if nc.jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(2*nc.jitter)+1)) - nc.jitter
+	}
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// lifetime returns how long a new connection stays up before it drops, 0
+// for as long as it is used
+func (nc *netConditions) lifetime() time.Duration {
+	if nc.drop <= 0 {
+		return 0
+	}
+	// drops are a Poisson process of the rate giving that chance per second
+	rate := -math.Log(1 - nc.drop)
+	return time.Duration(rand.ExpFloat64() / rate * float64(time.Second))
+}
+
+// chunk returns how many bytes of a transfer at rate go at once, 20ms worth
+// so that the transfer is paced smoothly
+func chunk(rate int64, size int) int {
+	n := int(rate / 50)
+	if n < 512 {
+		n = 512
+	}
+	if rate <= 0 || n > size {
+		return size
+	}
+	return n
+}
+
+// pace waits until a link at rate, busy until *busy, would have carried n
+// more bytes
+func pace(busy *time.Time, n int, rate int64) {
+	if rate <= 0 || n <= 0 {
+		return
+	}
+	now := time.Now()
+	if busy.Before(now) {
+		*busy = now
+	}
+	*busy = busy.Add(time.Duration(int64(n) * int64(time.Second) / rate))
+	time.Sleep(time.Until(*busy))
+}
+
+// emulate subjects blitzConn to the emulated network, scheduling its drop
+func (blitzConn *BlitzConn) emulate(nc *netConditions) {
+	blitzConn.netem = nc
+	if d := nc.lifetime(); d > 0 {
+		blitzConn.dropTimer = time.AfterFunc(d, func() {
+			atomic.StoreInt32(&blitzConn.dropped, 1)
+			blitzConn.Conn.Close()
+		})
+	}
+}
+
+// emulatedRead reads at most a chunk of the download bandwidth and paces it.
+// The first read after a write waits out the download half of the latency
+// the write drew, which delays every response by the rest of a round trip.
+func (blitzConn *BlitzConn) emulatedRead(b []byte) (int, error) {
+	nc := blitzConn.netem
+	n, err := blitzConn.read(b[:chunk(nc.down, len(b))])
+	if atomic.LoadInt32(&blitzConn.dropped) == 1 {
+		return n, errNetDrop
+	}
+	if n > 0 && atomic.CompareAndSwapInt32(&blitzConn.turn, 1, 0) {
+		time.Sleep(time.Duration(atomic.LoadInt64(&blitzConn.downLag)))
+	}
+	pace(&blitzConn.downBusy, n, nc.down)
+	return n, err
+}
+
+// emulatedWrite writes b a chunk of the upload bandwidth at a time. The
+// first write of a turn draws the latency of the round trip and holds its
+// bytes back for the upload half, so that the server sees them late.
+func (blitzConn *BlitzConn) emulatedWrite(b []byte) (n int, err error) {
+	nc := blitzConn.netem
+	if atomic.LoadInt32(&blitzConn.turn) == 0 {
+		d := nc.delay()
+		atomic.StoreInt64(&blitzConn.downLag, int64(d-d/2))
+		time.Sleep(d / 2)
+	}
+	for len(b) > 0 {
+		size := chunk(nc.up, len(b))
+		var written int
+		written, err = blitzConn.write(b[:size])
+		n += written
+		if atomic.LoadInt32(&blitzConn.dropped) == 1 {
+			return n, errNetDrop
+		}
+		if err != nil {
+			return n, err
+		}
+		pace(&blitzConn.upBusy, written, nc.up)
+		b = b[size:]
+	}
+	atomic.StoreInt32(&blitzConn.turn, 1)
+	return n, nil
+}
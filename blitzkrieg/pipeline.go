@@ -0,0 +1,216 @@
+package blitzkrieg
+
+import (
+	"net"
+	"net/http"
+	neturl "net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A pipelined request is on its way from the writer of a pipeConn to its
+// reader
+type pipelined struct {
+	req     *blitzRequest
+	hReq    *http.Request
+	start   time.Time // when the client took the job, dialing included
+	written time.Time
+	phases  [numPhases]time.Duration
+	newConn bool
+	reused  bool
+	depth   int32 // requests in flight on the connection when it was written
+	err     error // why the request could not be written
+}
+
+// A pipeConn is an HTTP/1.1 connection that keeps up to depth requests in
+// flight. The client writes requests as they come while a reader of its own
+// reads the responses, which the server sends in order, and matches them to
+// the requests.
+type pipeConn struct {
+	*rawConn
+	pending  chan *pipelined
+	slots    chan struct{} // one per request in flight
+	inflight int32
+	broken   chan struct{} // closed once the reader gave up on the connection
+	written  bool          // whether a request was written, so the next ones are reused
+}
+
+// A pipeClient is the pipelining side of a simulated client, with one
+// pipeConn per host
+type pipeClient struct {
+	blitz   *Blitz
+	raw     *rawClient // dials like the raw mode does
+	conns   map[string]*pipeConn
+	readers sync.WaitGroup
+}
+
+func (blitz *Blitz) newPipeClient() *pipeClient {
+	return &pipeClient{blitz: blitz, raw: blitz.newRawClient(), conns: make(map[string]*pipeConn)}
+}
+
+// send writes req on the connection to its host, once fewer than the
+// pipeline depth of requests are in flight on it
+func (pc *pipeClient) send(req *blitzRequest) {
+	hReq := req.getHttpRequest()
+	address := hReq.URL.Host
+	if hReq.URL.Port() == "" {
+		address = net.JoinHostPort(hReq.URL.Hostname(), map[string]string{"http": "80", "https": "443"}[hReq.URL.Scheme])
+	}
+	key := hReq.URL.Scheme + "://" + address
+	p := &pipelined{req: req, hReq: hReq, start: time.Now()}
+
+	var conn *pipeConn
+	for conn == nil {
+		if conn = pc.conns[key]; conn == nil {
+			raw, err := pc.raw.connect(hReq.URL.Scheme, address, &p.phases)
+			if err != nil {
+				p.err = err
+				pc.report(p, time.Now(), nil, nil, 0)
+				return
+			}
+			conn = &pipeConn{
+				rawConn: raw,
+				pending: make(chan *pipelined, pc.blitz.pipeline),
+				slots:   make(chan struct{}, pc.blitz.pipeline),
+				broken:  make(chan struct{}),
+			}
+			pc.conns[key] = conn
+			pc.readers.Add(1)
+			go func() {
+				pc.read(conn)
+				pc.readers.Done()
+			}()
+		}
+		conn.slots <- struct{}{}
+		select {
+		case <-conn.broken:
+			// the reader gave up on the connection, the request goes on a new one
+			<-conn.slots
+			pc.abandon(key)
+			conn = nil
+		default:
+		}
+	}
+	p.depth = atomic.AddInt32(&conn.inflight, 1)
+	p.newConn, p.reused = !conn.written, conn.written
+	conn.written = true
+	conn.SetWriteDeadline(time.Now().Add(time.Duration(pc.blitz.writeTimeout) * time.Millisecond))
+	p.err = hReq.Write(conn)
+	p.written = time.Now()
+	conn.pending <- p
+	if p.err != nil {
+		pc.abandon(key)
+	}
+}
+
+// abandon stops writing to the connection to key, leaving its reader to
+// report what is still in flight and close it
+func (pc *pipeClient) abandon(key string) {
+	close(pc.conns[key].pending)
+	delete(pc.conns, key)
+}
+
+// close waits for the responses still in flight
+func (pc *pipeClient) close() {
+	for key := range pc.conns {
+		pc.abandon(key)
+	}
+	pc.readers.Wait()
+}
+
+// read reports the responses of conn in the order of its requests. Once the
+// connection fails or the server closes it, the requests still in flight on
+// it fail too.
+func (pc *pipeClient) read(conn *pipeConn) {
+	blitz := pc.blitz
+	var (
+		err      error
+		closing  bool
+		giveUp   sync.Once
+		lastRead time.Time
+	)
+	for p := range conn.pending {
+		var (
+			resp     *http.Response
+			body     []byte
+			headSize int64
+		)
+		switch {
+		case p.err != nil:
+		case err != nil:
+			p.err = err
+		case closing:
+			p.err = errPipelineClosed
+		default:
+			// the response could not start before the ones ahead of it ended
+			waiting := p.written
+			if lastRead.After(waiting) {
+				p.phases[phaseBlocked] = lastRead.Sub(waiting)
+				waiting = lastRead
+			}
+			conn.SetReadDeadline(time.Now().Add(time.Duration(blitz.readTimeout) * time.Millisecond))
+			if _, err = conn.r.Peek(1); err == nil {
+				firstByte := time.Now()
+				p.phases[phaseTTFB] = firstByte.Sub(waiting)
+				resp, body, headSize, closing, err = readRawResponse(conn.r, p.hReq.Method == "HEAD")
+				if err == nil {
+					p.phases[phaseTransfer] = time.Since(firstByte)
+				}
+			}
+			p.err = err
+			lastRead = time.Now()
+		}
+		if p.err != nil || closing {
+			giveUp.Do(func() {
+				conn.Close()
+				close(conn.broken)
+			})
+		}
+		atomic.AddInt32(&conn.inflight, -1)
+		<-conn.slots
+		pc.report(p, time.Now(), resp, body, headSize)
+	}
+	giveUp.Do(func() {
+		conn.Close()
+		close(conn.broken)
+	})
+}
+
+// report reports the response to p, or the error that kept it from one
+func (pc *pipeClient) report(p *pipelined, end time.Time, resp *http.Response, body []byte, headSize int64) {
+	result := &blitzResult{
+		name:      p.req.name,
+		duration:  end.Sub(p.start),
+		phases:    p.phases,
+		newConn:   p.newConn,
+		reused:    p.reused,
+		streams:   p.depth,
+		headerOut: requestHeaderSize(p.hReq),
+		timestamp: end,
+	}
+	if p.err != nil {
+		result.err, result.errClass = p.err, classifyError(p.err)
+	} else {
+		result.failedChecks = p.req.verify(resp, body, result.duration)
+		result.success = (p.req.hasStatusCheck() || isSuccess(resp.StatusCode)) && len(result.failedChecks) == 0
+		result.statusCode, result.proto = resp.StatusCode, resp.Proto
+		result.bodySize, result.headerIn = int64(len(body)), headSize
+		if result.success {
+			result.contentLength = result.bodySize
+		}
+	}
+	if !result.success && pc.blitz.samples != nil {
+		pc.blitz.samples.capture(p.req, p.start, result.duration, resp, body, result.err, result.errClass, result.failedChecks)
+	}
+	pc.blitz.results <- result
+}
+
+// isPipelined tells whether req goes through the pipelined client
+func (blitz *Blitz) isPipelined(req *blitzRequest) bool {
+	if blitz.pipeline == 0 || req.rpc != nil || req.method == "RAW" {
+		return false
+	}
+	u, err := neturl.Parse(req.url)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
@@ -0,0 +1,363 @@
+package blitzkrieg
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/net/http/httpproxy"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schemes of the proxies connections can be tunnelled through. HTTP and
+// HTTPS proxies are asked for a tunnel with CONNECT, for plain http targets
+// too. With socks5 host names are resolved locally, with socks5h by the
+// proxy.
+const (
+	proxyHTTP    = "http"
+	proxyHTTPS   = "https" // TLS to the proxy itself
+	proxySOCKS5  = "socks5"
+	proxySOCKS5H = "socks5h"
+)
+
+var (
+	// allProxy is the proxy given with -proxy, every connection goes
+	// through it
+	allProxy *neturl.URL
+	// proxies maps the addresses of targets to the proxy the environment
+	// gives for them, nil for none
+	proxies = make(map[string]*neturl.URL)
+)
+
+// A proxyError is the failure of a proxy to open a tunnel
+type proxyError struct {
+	proxy string
+	err   error
+}
+
+func (e *proxyError) Error() string {
+	return "proxy " + e.proxy + ": " + e.err.Error()
+}
+
+func (e *proxyError) Unwrap() error {
+	return e.err
+}
+
+// A tunnelConn is a tunnel through an HTTP proxy, which reads first the
+// bytes the target sent along with the response to CONNECT
+type tunnelConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *tunnelConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// socks5Replies names the SOCKS5 reply codes
+var socks5Replies = map[byte]string{
+	1: "general failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// parseProxy parses the URL of a proxy, http:// when it has no scheme, and
+// fills in the default port of its scheme
+func parseProxy(raw string) (*neturl.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return checkProxy(u)
+}
+
+func checkProxy(u *neturl.URL) (*neturl.URL, error) {
+	ports := map[string]string{proxyHTTP: "80", proxyHTTPS: "443", proxySOCKS5: "1080", proxySOCKS5H: "1080"}
+	port, ok := ports[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("%s: proxies are http, https, socks5 or socks5h", u.Redacted())
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%s: the proxy has no host", u.Redacted())
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+// targetAddress returns the host and port connections to u are dialed to
+func targetAddress(u *neturl.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	switch u.Scheme {
+	case "https", "wss", "grpcs":
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// proxyRequest looks up the proxy the environment gives for the host of req,
+// with -proxy-env. HTTP_PROXY, HTTPS_PROXY and NO_PROXY apply as they do to
+// Go clients, which never proxy localhost; ALL_PROXY stands in for the first
+// two. WebSocket and gRPC URLs go by their http or https counterpart.
+func proxyRequest(req *blitzRequest) error {
+	if !proxyEnv {
+		return nil
+	}
+	u, err := neturl.Parse(req.url)
+	if err != nil {
+		return nil
+	}
+	address := targetAddress(u)
+	if _, ok := proxies[address]; ok {
+		return nil
+	}
+	cfg := httpproxy.FromEnvironment()
+	all := os.Getenv("ALL_PROXY")
+	if all == "" {
+		all = os.Getenv("all_proxy")
+	}
+	if cfg.HTTPProxy == "" {
+		cfg.HTTPProxy = all
+	}
+	if cfg.HTTPSProxy == "" {
+		cfg.HTTPSProxy = all
+	}
+	target := *u
+	switch u.Scheme {
+	case "ws", "grpc":
+		target.Scheme = "http"
+	case "wss", "grpcs":
+		target.Scheme = "https"
+	}
+	proxy, err := cfg.ProxyFunc()(&target)
+	if err == nil && proxy != nil {
+		proxy, err = checkProxy(proxy)
+	}
+	if err != nil {
+		return err
+	}
+	proxies[address] = proxy
+	return nil
+}
+
+// proxyFor returns the proxy to dial address through, nil for none
+func proxyFor(address string) *neturl.URL {
+	if allProxy != nil {
+		return allProxy
+	}
+	return proxies[address]
+}
+
+// dialTarget dials address with dialer, or the Unix socket or the proxy it
+// goes through, resolving host names with the DNS options. With a proxy, it
+// returns how long the proxy took to open the tunnel once connected to.
+func dialTarget(ctx context.Context, dialer *net.Dialer, network string, address string) (net.Conn, time.Duration, error) {
+	network, address = unixAddress(network, address)
+	proxy := proxyFor(address)
+	if network == "unix" {
+		conn, err := dialer.DialContext(ctx, network, address)
+		return conn, 0, err
+	}
+	if proxy == nil {
+		conn, err := targetDNS.dialContext(ctx, dialer, network, address)
+		return conn, 0, err
+	}
+	conn, err := targetDNS.dialContext(ctx, dialer, "tcp", proxy.Host)
+	if err != nil {
+		return nil, 0, err
+	}
+	s := time.Now()
+	if dialer.Timeout > 0 {
+		conn.SetDeadline(s.Add(dialer.Timeout))
+	}
+	if conn, err = proxyTunnel(ctx, conn, proxy, address); err != nil {
+		return nil, 0, &proxyError{proxy: proxy.Redacted(), err: err}
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, time.Since(s), nil
+}
+
+// proxyTunnel opens a tunnel to address through proxy over conn, the
+// connection to the proxy, and closes conn if it cannot
+func proxyTunnel(ctx context.Context, conn net.Conn, proxy *neturl.URL, address string) (net.Conn, error) {
+	var err error
+	tunnel := conn
+	if proxy.Scheme == proxyHTTPS {
+		tlsConn := tls.Client(conn, tlsFor(proxyTLS, proxy.Hostname()))
+		err = tlsConn.HandshakeContext(ctx)
+		tunnel = tlsConn
+	}
+	if err == nil {
+		switch proxy.Scheme {
+		case proxyHTTP, proxyHTTPS:
+			tunnel, err = connectTunnel(tunnel, proxy, address)
+		default:
+			err = socks5Tunnel(ctx, tunnel, proxy, address)
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tunnel, nil
+}
+
+// connectTunnel asks an HTTP proxy for a tunnel to address with CONNECT
+func connectTunnel(conn net.Conn, proxy *neturl.URL, address string) (net.Conn, error) {
+	hReq := &http.Request{
+		Method: "CONNECT",
+		URL:    &neturl.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	hReq.Header.Set("User-Agent", "blitz "+VERSION)
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		hReq.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := hReq.Write(conn); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, hReq)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CONNECT %s: %s", address, resp.Status)
+	}
+	return &tunnelConn{Conn: conn, r: r}, nil
+}
+
+// socks5Tunnel asks a SOCKS5 proxy for a tunnel to address, authenticating
+// with the user and password of the proxy URL when it has them
+func socks5Tunnel(ctx context.Context, conn net.Conn, proxy *neturl.URL, address string) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return err
+	}
+
+	methods := []byte{0x00}
+	if proxy.User != nil {
+		methods = append(methods, 0x02)
+	}
+	if _, err = conn.Write(append([]byte{0x05, byte(len(methods))}, methods...)); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err = io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != 0x05 {
+		return errors.New("not a SOCKS5 proxy")
+	}
+	switch reply[1] {
+	case 0x00:
+	case 0x02:
+		if proxy.User == nil {
+			return errors.New("SOCKS5 proxy wants a user and password")
+		}
+		user := proxy.User.Username()
+		password, _ := proxy.User.Password()
+		if len(user) > 255 || len(password) > 255 {
+			return errors.New("SOCKS5 user and password are up to 255 bytes")
+		}
+		auth := append([]byte{0x01, byte(len(user))}, user...)
+		auth = append(append(auth, byte(len(password))), password...)
+		if _, err = conn.Write(auth); err != nil {
+			return err
+		}
+		if _, err = io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return errors.New("SOCKS5 authentication failed")
+		}
+	default:
+		return errors.New("no acceptable SOCKS5 authentication method")
+	}
+
+	request := []byte{0x05, 0x01, 0x00}
+	ip := net.ParseIP(host)
+	if ip == nil && proxy.Scheme == proxySOCKS5 {
+		ips, err := targetDNS.lookup(ctx, host, portStr)
+		if err != nil {
+			return err
+		}
+		if len(ips) == 0 {
+			return &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		ip = ips[targetDNS.pick(address, ips)]
+	}
+	switch {
+	case ip.To4() != nil:
+		request = append(append(request, 0x01), ip.To4()...)
+	case ip != nil:
+		request = append(append(request, 0x04), ip.To16()...)
+	case len(host) > 255:
+		return fmt.Errorf("%s: SOCKS5 host names are up to 255 bytes", host)
+	default:
+		request = append(append(request, 0x03, byte(len(host))), host...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err = conn.Write(request); err != nil {
+		return err
+	}
+
+	// the reply ends with the address the proxy bound, of the type it gives
+	head := make([]byte, 4)
+	if _, err = io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	if head[1] != 0x00 {
+		if reason, ok := socks5Replies[head[1]]; ok {
+			return fmt.Errorf("SOCKS5 %s: %s", address, reason)
+		}
+		return fmt.Errorf("SOCKS5 %s: reply %d", address, head[1])
+	}
+	var bound int
+	switch head[3] {
+	case 0x01:
+		bound = net.IPv4len
+	case 0x04:
+		bound = net.IPv6len
+	case 0x03:
+		size := make([]byte, 1)
+		if _, err = io.ReadFull(conn, size); err != nil {
+			return err
+		}
+		bound = int(size[0])
+	default:
+		return fmt.Errorf("SOCKS5 address type %d", head[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, bound+2))
+	return err
+}
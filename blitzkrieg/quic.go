@@ -0,0 +1,180 @@
+package blitzkrieg
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// protoH3 is HTTP/3 over QUIC
+const protoH3 = "h3"
+
+// quicStats follows the QUIC connections of an h3 test: how long their
+// handshakes took, how many resumed a TLS session and how many of those
+// sent their first requests as 0-RTT data
+type quicStats struct {
+	sync.Mutex
+	handshakes *histogram
+	resumed    int64
+	used0RTT   int64
+	failed     int64                  // connections that never completed the handshake
+	conns      map[string]*packetConn // open connections by local address
+	sessions   tls.ClientSessionCache // shared by the transports, so that new connections can resume
+}
+
+func newQuicStats() *quicStats {
+	return &quicStats{
+		handshakes: newHistogram(),
+		conns:      make(map[string]*packetConn),
+		sessions:   tls.NewLRUClientSessionCache(0),
+	}
+}
+
+// inflight returns the in-flight request counter of the QUIC connection
+// behind conn, which http3 only hands out to traces as a stand-in
+func (qs *quicStats) inflight(conn net.Conn) *int32 {
+	qs.Lock()
+	defer qs.Unlock()
+	if pc, ok := qs.conns[conn.LocalAddr().String()]; ok {
+		return &pc.streams
+	}
+	return nil
+}
+
+// A packetConn is the UDP socket of a single QUIC connection. Like BlitzConn
+// it counts the bytes on the wire.
+type packetConn struct {
+	net.PacketConn
+	stats   *connStats
+	streams int32 // requests in flight on the connection
+}
+
+func (pc *packetConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := pc.PacketConn.ReadFrom(b)
+	atomic.AddInt64(&pc.stats.bytesIn, int64(n))
+	return n, addr, err
+}
+
+func (pc *packetConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	n, err := pc.PacketConn.WriteTo(b, addr)
+	atomic.AddInt64(&pc.stats.bytesOut, int64(n))
+	return n, err
+}
+
+// h3Transport returns an HTTP/3 round tripper. Its sessions are always
+// resumed, from a cache all of them share, for 0-RTT.
+func (blitz *Blitz) h3Transport() http.RoundTripper {
+	tlsConfig := targetTLS.Clone()
+	tlsConfig.ClientSessionCache = blitz.quic.sessions
+	return &http3.Transport{
+		TLSClientConfig: tlsConfig,
+		QUICConfig: &quic.Config{
+			HandshakeIdleTimeout: time.Duration(blitz.connectTimeout) * time.Millisecond,
+			MaxIdleTimeout:       time.Duration(blitz.readTimeout) * time.Millisecond,
+		},
+		DisableCompression: !blitz.gzip,
+		Dial:               blitz.dialQUIC,
+	}
+}
+
+// dialQUIC opens a QUIC connection to address over a socket of its own and
+// follows it until it closes
+func (blitz *Blitz) dialQUIC(ctx context.Context, address string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := targetDNS.lookup(ctx, host, portStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+	}
+	udpAddr := &net.UDPAddr{IP: ips[targetDNS.pick(address, ips)], Port: port}
+	udp, err := listenFrom()
+	if err != nil {
+		return nil, err
+	}
+	pc := &packetConn{PacketConn: udp, stats: blitz.conns}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	start := time.Now()
+	conn, err := quic.DialEarly(ctx, pc, udpAddr, tlsCfg, cfg)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		var state tls.ConnectionState
+		if conn != nil {
+			state = conn.ConnectionState().TLS
+		}
+		trace.TLSHandshakeDone(state, err)
+	}
+	qs := blitz.quic
+	if err != nil {
+		udp.Close()
+		qs.Lock()
+		qs.failed++
+		qs.Unlock()
+		blitz.tls.record(tls.ConnectionState{}, 0, err)
+		// as net.Dialer does, so that errors are classified alike
+		return nil, &net.OpError{Op: "dial", Net: "udp", Addr: udpAddr, Err: err}
+	}
+	blitz.conns.opened()
+	targetDNS.connected(udpAddr.String())
+	qs.Lock()
+	qs.conns[udp.LocalAddr().String()] = pc
+	qs.Unlock()
+
+	go func() {
+		select {
+		case <-conn.HandshakeComplete():
+			state := conn.ConnectionState()
+			blitz.tls.record(state.TLS, time.Since(start), nil)
+			qs.Lock()
+			qs.handshakes.record(time.Since(start))
+			if state.TLS.DidResume {
+				qs.resumed++
+			}
+			if state.Used0RTT {
+				qs.used0RTT++
+			}
+			qs.Unlock()
+		case <-conn.Context().Done():
+			qs.Lock()
+			qs.failed++
+			qs.Unlock()
+			blitz.tls.record(tls.ConnectionState{}, 0, conn.Context().Err())
+		}
+		<-conn.Context().Done()
+		qs.Lock()
+		delete(qs.conns, udp.LocalAddr().String())
+		qs.Unlock()
+		udp.Close()
+		atomic.AddInt64(&blitz.conns.open, -1)
+	}()
+	return conn, nil
+}
+
+// early marks GET and HEAD requests as safe to send in 0-RTT data
+func early(hReq *http.Request) {
+	switch hReq.Method {
+	case "GET":
+		hReq.Method = http3.MethodGet0RTT
+	case "HEAD":
+		hReq.Method = http3.MethodHead0RTT
+	}
+}
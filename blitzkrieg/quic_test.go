@@ -0,0 +1,168 @@
+package blitzkrieg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// selfSigned returns a certificate for 127.0.0.1 signed by its own key
+func selfSigned(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "blitz test"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startH3 serves handler over HTTP/3 on a loopback UDP port, accepting 0-RTT
+// data, and returns the URL of its root
+func startH3(t *testing.T, handler http.Handler) string {
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http3.Server{
+		Handler:    handler,
+		TLSConfig:  http3.ConfigureTLSConfig(&tls.Config{Certificates: []tls.Certificate{selfSigned(t)}}),
+		QUICConfig: &quic.Config{Allow0RTT: true},
+	}
+	go srv.Serve(udp)
+	t.Cleanup(func() {
+		srv.Close()
+		udp.Close()
+	})
+	return "https://" + udp.LocalAddr().String() + "/"
+}
+
+// newH3Blitz returns a Blitz speaking h3 with a single client, ready for its
+// raider to send n requests
+func newH3Blitz(n int, keepAlive bool, zeroRTT bool) *Blitz {
+	blitz := &Blitz{
+		count:          n,
+		clients:        1,
+		keepAlive:      keepAlive,
+		connectTimeout: 2000,
+		readTimeout:    2000,
+		writeTimeout:   2000,
+		proto:          protoH3,
+		connsPerClient: 1,
+		streams:        1,
+		zeroRTT:        zeroRTT,
+	}
+	blitz.conns = &connStats{}
+	blitz.tls = newTLSStats()
+	blitz.quic = newQuicStats()
+	blitz.bar = newPBar(n)
+	return blitz
+}
+
+// raid sends n requests to url through a single raider and returns their
+// results
+func raid(blitz *Blitz, url string) []*blitzResult {
+	req := &blitzRequest{name: "GET " + url, url: url, method: "GET", header: http.Header{}}
+	blitz.jobs = make(chan *blitzRequest, blitz.count)
+	blitz.results = make(chan *blitzResult, blitz.count)
+	for i := 0; i < blitz.count; i++ {
+		blitz.jobs <- req
+	}
+	close(blitz.jobs)
+	blitz.raider()
+	close(blitz.results)
+	var results []*blitzResult
+	for result := range blitz.results {
+		results = append(results, result)
+	}
+	return results
+}
+
+// handshakes waits for the handshakes of n QUIC connections to be recorded,
+// which happens once they complete and may come after the responses of 0-RTT
+// requests, and returns the number recorded, resumed and with 0-RTT data
+func handshakes(qs *quicStats, n int64) (count int64, resumed int64, used0RTT int64) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		qs.Lock()
+		count, resumed, used0RTT = qs.handshakes.count, qs.resumed, qs.used0RTT
+		qs.Unlock()
+		if count >= n || time.Now().After(deadline) {
+			return count, resumed, used0RTT
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestH3Load(t *testing.T) {
+	url := startH3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	blitz := newH3Blitz(20, true, false)
+	results := append(raid(blitz, url), raid(newH3Blitz(5, true, false), url+"missing")...)
+
+	codes := make(map[int]int)
+	for _, result := range results {
+		if result.err != nil {
+			t.Fatalf("%s: %v", result.name, result.err)
+		}
+		if result.proto != "HTTP/3.0" {
+			t.Errorf("%s: got proto %s, want HTTP/3.0", result.name, result.proto)
+		}
+		codes[result.statusCode]++
+	}
+	if codes[200] != 20 || codes[404] != 5 || len(codes) != 2 {
+		t.Errorf("got status codes %v, want 20 200s and 5 404s", codes)
+	}
+	count, resumed, _ := handshakes(blitz.quic, 1)
+	blitz.quic.Lock()
+	failed := blitz.quic.failed
+	blitz.quic.Unlock()
+	if count != 1 || resumed != 0 || failed != 0 {
+		t.Errorf("got %d handshakes, %d resumed, %d failed, want a single full one", count, resumed, failed)
+	}
+}
+
+func TestH3ZeroRTT(t *testing.T) {
+	url := startH3(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	// without keep-alive every request has a connection of its own, the
+	// ones after the first resume its session and send their request early
+	blitz := newH3Blitz(3, false, true)
+	for _, result := range raid(blitz, url) {
+		if result.err != nil || result.statusCode != 200 {
+			t.Fatalf("got status %d, error %v, want 200", result.statusCode, result.err)
+		}
+	}
+	count, resumed, used0RTT := handshakes(blitz.quic, 3)
+	if count != 3 || resumed != 2 || used0RTT == 0 {
+		t.Errorf("got %d handshakes, %d resumed, %d with 0-RTT, want 3, 2 and some",
+			count, resumed, used0RTT)
+	}
+}
@@ -0,0 +1,352 @@
+package blitzkrieg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	neturl "net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadRaw replaces the body of a RAW request, the name of a file, with the
+// bytes of the file
+func loadRaw(req *blitzRequest) error {
+	u, err := neturl.Parse(req.url)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%s: raw requests go to http or https URLs", req.url)
+	}
+	data, err := ioutil.ReadFile(req.body)
+	if err != nil {
+		return err
+	}
+	req.rawMethods = rawRequestMethods(data)
+	if req.rawCount = len(req.rawMethods); req.rawCount == 0 {
+		return fmt.Errorf("%s is empty", req.body)
+	}
+	req.rawFile, req.body = req.body, string(data)
+	req.name = "RAW " + filepath.Base(req.rawFile)
+	return nil
+}
+
+// rawRequestMethods returns the methods of the requests in the bytes of a
+// raw request, each a head ended by a blank line and a body of its
+// Content-Length, so that as many responses are read back, without bodies
+// for HEAD. Bytes that do not end their head still make a request, which the
+// server should at least answer with an error.
+func rawRequestMethods(data []byte) []string {
+	var methods []string
+	for {
+		data = bytes.TrimLeft(data, "\r\n")
+		if len(data) == 0 {
+			return methods
+		}
+		method := data
+		if i := bytes.IndexAny(method, " \r\n"); i >= 0 {
+			method = method[:i]
+		}
+		methods = append(methods, string(method))
+		end, sep := bytes.Index(data, []byte("\n\r\n")), 3
+		if lf := bytes.Index(data, []byte("\n\n")); lf >= 0 && (end < 0 || lf < end) {
+			end, sep = lf, 2
+		}
+		if end < 0 {
+			return methods
+		}
+		head := data[:end]
+		data = data[end+sep:]
+		for _, line := range strings.Split(string(head), "\n") {
+			name, value, ok := strings.Cut(line, ":")
+			if !ok || !strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+				continue
+			}
+			if length, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && length > 0 {
+				if length > len(data) {
+					length = len(data)
+				}
+				data = data[length:]
+			}
+		}
+	}
+}
+
+// readRawResponse parses a response off r as leniently as it can: the status
+// line, the headers, and a body framed by chunked encoding, Content-Length or
+// the end of the connection; noBody is for responses to HEAD. Interim 1xx
+// responses are skipped. It returns the size of the head and whether the
+// server is closing the connection.
+func readRawResponse(r *bufio.Reader, noBody bool) (resp *http.Response, body []byte, headSize int64, closing bool, err error) {
+	inHead := true
+	readLine := func() (string, error) {
+		line, err := r.ReadString('\n')
+		if inHead {
+			headSize += int64(len(line))
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+	for {
+		var line string
+		if line, err = readLine(); err != nil {
+			return
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "HTTP/") {
+			err = fmt.Errorf("malformed status line: %q", line)
+			return
+		}
+		resp = &http.Response{Proto: fields[0], Status: strings.Join(fields[1:], " "), Header: make(http.Header)}
+		if resp.StatusCode, err = strconv.Atoi(fields[1]); err != nil {
+			err = fmt.Errorf("malformed status line: %q", line)
+			return
+		}
+		for {
+			if line, err = readLine(); err != nil {
+				return
+			}
+			if line == "" {
+				break
+			}
+			if name, value, ok := strings.Cut(line, ":"); ok {
+				resp.Header.Add(textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name)), strings.TrimSpace(value))
+			}
+		}
+		if resp.StatusCode >= 200 || resp.StatusCode == http.StatusSwitchingProtocols {
+			break
+		}
+	}
+
+	inHead = false
+	connection := strings.ToLower(resp.Header.Get("Connection"))
+	closing = strings.Contains(connection, "close") ||
+		resp.Proto == "HTTP/1.0" && !strings.Contains(connection, "keep-alive")
+	switch {
+	case noBody, resp.StatusCode < 200, resp.StatusCode == http.StatusNoContent, resp.StatusCode == http.StatusNotModified:
+	case strings.Contains(strings.ToLower(resp.Header.Get("Transfer-Encoding")), "chunked"):
+		for size := int64(-1); size != 0; {
+			var line string
+			if line, err = readLine(); err != nil {
+				return
+			}
+			sizeStr, _, _ := strings.Cut(line, ";")
+			if size, err = strconv.ParseInt(strings.TrimSpace(sizeStr), 16, 64); err != nil || size < 0 {
+				err = fmt.Errorf("malformed chunk size: %q", line)
+				return
+			}
+			if size > 0 {
+				chunk := make([]byte, size)
+				if _, err = io.ReadFull(r, chunk); err != nil {
+					return
+				}
+				body = append(body, chunk...)
+				if _, err = readLine(); err != nil {
+					return
+				}
+			}
+		}
+		// trailers, up to the blank line
+		for line := "-"; line != ""; {
+			if line, err = readLine(); err != nil {
+				return
+			}
+		}
+	case resp.Header.Get("Content-Length") != "":
+		var length int64
+		if length, err = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err != nil || length < 0 {
+			err = fmt.Errorf("malformed Content-Length: %q", resp.Header.Get("Content-Length"))
+			return
+		}
+		body = make([]byte, length)
+		_, err = io.ReadFull(r, body)
+	default:
+		// no framing, the body runs to the end of the connection
+		body, err = ioutil.ReadAll(r)
+		closing = true
+	}
+	return
+}
+
+// A rawConn is a connection of a rawClient along with its buffered reader
+type rawConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// A rawClient is the raw side of a simulated client. The bytes of a raw
+// request are written as they are over a connection to the host of its URL,
+// TLS for https, which is kept for the next request while the server keeps
+// it open and keep-alive is on.
+type rawClient struct {
+	blitz *Blitz
+	conns map[string]*rawConn
+	tls   *tls.Config
+}
+
+func (blitz *Blitz) newRawClient() *rawClient {
+	return &rawClient{blitz: blitz, conns: make(map[string]*rawConn), tls: clientTLS()}
+}
+
+// connect dials address, timing the connect and TLS phases
+func (rc *rawClient) connect(scheme string, address string, phases *[numPhases]time.Duration) (*rawConn, error) {
+	ctx := context.Background()
+	s := time.Now()
+	conn, err := rc.blitz.dial(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	phases[phaseConnect] = time.Since(s)
+	if bConn := blitzConnOf(conn); bConn != nil {
+		phases[phaseConnect] -= bConn.proxy
+		phases[phaseProxy] = bConn.proxy
+	}
+	if scheme == "https" {
+		host, _, _ := net.SplitHostPort(address)
+		s = time.Now()
+		if conn, err = rc.blitz.tlsHandshake(ctx, conn, tlsFor(rc.tls, host)); err != nil {
+			return nil, err
+		}
+		phases[phaseTLS] = time.Since(s)
+	}
+	return &rawConn{Conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// send writes the bytes of req and reports every response read back as a
+// result of its own
+func (rc *rawClient) send(req *blitzRequest) {
+	blitz := rc.blitz
+	u, _ := neturl.Parse(req.url)
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), map[string]string{"http": "80", "https": "443"}[u.Scheme])
+	}
+	key := u.Scheme + "://" + address
+
+	var phases [numPhases]time.Duration
+	s := time.Now()
+	conn := rc.conns[key]
+	reused := conn != nil
+	var (
+		err   error
+		wrote time.Time
+	)
+	for {
+		if conn == nil {
+			conn, err = rc.connect(u.Scheme, address, &phases)
+		}
+		if err == nil {
+			_, err = io.WriteString(conn, req.body)
+			wrote = time.Now()
+		}
+		if err == nil {
+			_, err = conn.r.Peek(1)
+		}
+		if err == nil || !reused {
+			break
+		}
+		// the server closed the kept connection while it was idle, which
+		// is no failure of the request: it goes again on a new connection
+		conn.Close()
+		delete(rc.conns, key)
+		conn, reused, err = nil, false, nil
+	}
+	for i := 0; err == nil && i < req.rawCount; i++ {
+		var (
+			resp     *http.Response
+			body     []byte
+			headSize int64
+			closing  bool
+		)
+		if _, err = conn.r.Peek(1); err != nil {
+			break
+		}
+		if i == 0 {
+			phases[phaseTTFB] = time.Since(wrote)
+		}
+		if resp, body, headSize, closing, err = readRawResponse(conn.r, req.rawMethods[i] == "HEAD"); err != nil {
+			break
+		}
+		end := time.Now()
+		failed := req.verify(resp, body, end.Sub(s))
+		success := (req.hasStatusCheck() || isSuccess(resp.StatusCode)) && len(failed) == 0
+		if !success && blitz.samples != nil {
+			blitz.samples.capture(req, s, end.Sub(s), resp, body, nil, "", failed)
+		}
+		result := &blitzResult{
+			name:         req.name,
+			statusCode:   resp.StatusCode,
+			duration:     end.Sub(s),
+			phases:       phases,
+			newConn:      !reused && i == 0,
+			reused:       reused || i > 0,
+			success:      success,
+			failedChecks: failed,
+			proto:        resp.Proto,
+			bodySize:     int64(len(body)),
+			headerIn:     headSize,
+			timestamp:    end,
+		}
+		if success {
+			result.contentLength = int64(len(body))
+		}
+		blitz.results <- result
+		phases = [numPhases]time.Duration{}
+		if closing {
+			conn.Close()
+			delete(rc.conns, key)
+			return
+		}
+	}
+	if err != nil {
+		end := time.Now()
+		errClass := classifyError(err)
+		if blitz.samples != nil {
+			blitz.samples.capture(req, s, end.Sub(s), nil, nil, err, errClass, nil)
+		}
+		blitz.results <- &blitzResult{
+			name:      req.name,
+			duration:  end.Sub(s),
+			phases:    phases,
+			reused:    reused,
+			err:       err,
+			errClass:  errClass,
+			timestamp: end,
+		}
+		if conn != nil {
+			conn.Close()
+			delete(rc.conns, key)
+		}
+		return
+	}
+	if blitz.keepAlive {
+		rc.conns[key] = conn
+	} else {
+		conn.Close()
+	}
+}
+
+// rawCommand returns a command line writing the bytes of a raw request
+func rawCommand(req *blitzRequest) string {
+	u, _ := neturl.Parse(req.url)
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	if network, socket := unixAddress("tcp", u.Host); network == "unix" {
+		return fmt.Sprintf("nc -U %s < %s", shellQuote(socket), shellQuote(req.rawFile))
+	}
+	if u.Scheme == "https" {
+		return fmt.Sprintf("openssl s_client -quiet -connect %s < %s", shellQuote(net.JoinHostPort(u.Hostname(), port)), shellQuote(req.rawFile))
+	}
+	return fmt.Sprintf("nc %s %s < %s", shellQuote(u.Hostname()), port, shellQuote(req.rawFile))
+}
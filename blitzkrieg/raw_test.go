@@ -0,0 +1,127 @@
+package blitzkrieg
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRawRequestMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"blank lines", "\r\n\r\n", nil},
+		{"single", "GET / HTTP/1.1\r\nHost: a\r\n\r\n", []string{"GET"}},
+		{"lf only", "GET / HTTP/1.1\nHost: a\n\nHEAD / HTTP/1.1\nHost: a\n\n", []string{"GET", "HEAD"}},
+		{"mixed endings", "GET / HTTP/1.1\nHost: a\r\n\r\nDELETE /x HTTP/1.1\r\n\r\n", []string{"GET", "DELETE"}},
+		{"body", "POST / HTTP/1.1\r\nContent-Length: 18\r\n\r\nGET / HTTP/1.1\r\n\r\n" +
+			"PUT / HTTP/1.1\r\n\r\n", []string{"POST", "PUT"}},
+		{"body lf only", "POST / HTTP/1.1\ncontent-length : 5\n\nhello\nGET / HTTP/1.1\n\n", []string{"POST", "GET"}},
+		{"short body", "POST / HTTP/1.1\r\nContent-Length: 100\r\n\r\nhello", []string{"POST"}},
+		{"bad length", "POST / HTTP/1.1\r\nContent-Length: x\r\n\r\nGET / HTTP/1.1\r\n\r\n", []string{"POST", "GET"}},
+		{"unended head", "GET / HTTP/1.1\r\nHost: a\r\n\r\nGET /more HTTP/1.1\r\nHost: a", []string{"GET", "GET"}},
+		{"garbage", "hello", []string{"hello"}},
+	}
+	for _, test := range tests {
+		if got := rawRequestMethods([]byte(test.data)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got methods %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReadRawResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		noBody   bool
+		status   int
+		body     string
+		headSize int64
+		closing  bool
+		err      bool
+	}{
+		{name: "content length", data: "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhello",
+			status: 200, body: "hello", headSize: 38},
+		{name: "lf only", data: "HTTP/1.1 200 OK\nContent-Length: 5\n\nhello",
+			status: 200, body: "hello", headSize: 35},
+		{name: "chunked", data: "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n" +
+			"5\r\nhello\r\n7;ext=1\r\n, world\r\n0\r\n\r\n",
+			status: 200, body: "hello, world", headSize: 47},
+		{name: "chunked trailers", data: "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n" +
+			"5\r\nhello\r\n0\r\nX-Checksum: 1\r\nX-More: 2\r\n\r\n",
+			status: 200, body: "hello", headSize: 47},
+		{name: "bad chunk", data: "HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n",
+			status: 200, err: true},
+		{name: "interim", data: "HTTP/1.1 100 Continue\r\n\r\nHTTP/1.1 103 Early Hints\r\nLink: </a>\r\n\r\n" +
+			"HTTP/1.1 201 Created\r\nContent-Length: 2\r\n\r\nok",
+			status: 201, body: "ok", headSize: 25 + 40 + 43},
+		{name: "switching", data: "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\n",
+			status: 101, headSize: 56},
+		{name: "head", data: "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n", noBody: true,
+			status: 200, headSize: 38},
+		{name: "no content", data: "HTTP/1.1 204 No Content\r\n\r\n",
+			status: 204, headSize: 27},
+		{name: "close delimited", data: "HTTP/1.1 200 OK\r\n\r\nall of it\r\nto the end",
+			status: 200, body: "all of it\r\nto the end", headSize: 19, closing: true},
+		{name: "connection close", data: "HTTP/1.1 200 OK\r\nConnection: close\r\nContent-Length: 2\r\n\r\nok",
+			status: 200, body: "ok", headSize: 57, closing: true},
+		{name: "http/1.0", data: "HTTP/1.0 200 OK\r\nContent-Length: 2\r\n\r\nok",
+			status: 200, body: "ok", headSize: 38, closing: true},
+		{name: "http/1.0 keep-alive", data: "HTTP/1.0 200 OK\r\nConnection: Keep-Alive\r\nContent-Length: 2\r\n\r\nok",
+			status: 200, body: "ok", headSize: 62},
+		{name: "short body", data: "HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\nhello",
+			status: 200, err: true},
+		{name: "bad length", data: "HTTP/1.1 200 OK\r\nContent-Length: -1\r\n\r\n",
+			status: 200, err: true},
+		{name: "bad status", data: "SSH-2.0-OpenSSH\r\n", err: true},
+		{name: "bad code", data: "HTTP/1.1 OK\r\n\r\n", err: true},
+		{name: "empty", data: "", err: true},
+	}
+	for _, test := range tests {
+		resp, body, headSize, closing, err := readRawResponse(bufio.NewReader(strings.NewReader(test.data)), test.noBody)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: got no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if resp.StatusCode != test.status || string(body) != test.body {
+			t.Errorf("%s: got status %d, body %q, want %d and %q", test.name, resp.StatusCode, body, test.status, test.body)
+		}
+		if headSize != test.headSize || closing != test.closing {
+			t.Errorf("%s: got head size %d, closing %v, want %d and %v", test.name, headSize, closing, test.headSize, test.closing)
+		}
+	}
+}
+
+// TestReadRawResponses reads pipelined responses off a single reader, each
+// having to stop at the end of its own body
+func TestReadRawResponses(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("HTTP/1.1 200 OK\r\nContent-Length: 1\r\n\r\na" +
+		"HTTP/1.1 200 OK\r\nContent-Length: 1\r\n\r\n" +
+		"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n1\r\nc\r\n0\r\nX-T: 1\r\n\r\n" +
+		"HTTP/1.1 404 Not Found\r\nContent-Length: 1\r\n\r\nd"))
+	var bodies []string
+	for _, noBody := range []bool{false, true, false, false} {
+		resp, body, _, _, err := readRawResponse(r, noBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, resp.Status+" "+string(body))
+	}
+	want := []string{"200 OK a", "200 OK ", "200 OK c", "404 Not Found d"}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("got responses %q, want %q", bodies, want)
+	}
+	if _, err := r.Peek(1); err == nil {
+		t.Errorf("bytes left after the last response")
+	}
+}
@@ -3,121 +3,361 @@ package blitzkrieg
 import (
 	"bytes"
 	"fmt"
-	"github.com/bmizerany/perks/quantile"
+	"google.golang.org/grpc/codes"
 	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"text/tabwriter"
 	"time"
 )
 
 // A blitzResult represents the result of an http Request
 type blitzResult struct {
+	name          string
 	err           error
+	errClass      string   // category of err
+	success       bool     // the response passed the status range or the checks
+	failedChecks  []string // names of the checks the response failed
 	statusCode    int
 	duration      time.Duration
+	phases        [numPhases]time.Duration // zero when the phase did not happen
+	newConn       bool                     // sent over a freshly dialed connection
+	reused        bool                     // sent over a kept-alive connection
 	contentLength int64
+	proto         string // protocol of the response
+	streams       int32  // requests in flight on the connection when it was sent
+	bodySize      int64  // decoded size of the response body
+	headerIn      int64  // size of the response headers over http1.1, estimated unless read raw
+	headerOut     int64  // estimated size of the request headers
+	wireIn        int64  // bytes of the response on the wire, 0 unless its connection was its own
+	wireOut       int64  // bytes of the request on the wire, likewise
 	timestamp     time.Time
+	events        *eventTimes // with an event mode, when the body was read
 }
 
-type plot struct {
-	elapsed        float64
-	latencySuccess string
-	latencyErr     string
-}
-
-type graphPlots []*plot
-
-func (gp graphPlots) Len() int           { return len(gp) }
-func (gp graphPlots) Swap(i, j int)      { gp[i], gp[j] = gp[j], gp[i] }
-func (gp graphPlots) Less(i, j int) bool { return gp[i].elapsed < gp[j].elapsed }
-
 // report represents the results of the load test
 type report struct {
-	statusCodes     map[int]int
-	errors          map[string]int
-	latencies       []float64
+	statusCodes     map[int]int64
+	errors          map[string]*errorClass
+	checks          map[string]int64 // failures by check name
+	checkFailures   int64
+	thresholds      []*thresholdResult
+	aborted         string // why the run was cut short, if it was
+	samplesFile     string // HAR file of sample failures, if any
 	percentile50Lat float64
 	percentile99Lat float64
 	maxLat          float64
 	avgLat          float64
 	totalTime       float64
-	totalTimeSum    float64
 	totalSize       int64
 	totalRequests   int64
 	totalSuccess    int64
 	totalHttpErrors int64
 	rate            float64
-	graphData       graphPlots
+	interval        float64 // length of the time series intervals in seconds
+	series          []*intervalStat
+	names           []*nameReport
+	phases          [numPhases]*phaseReport
+	newConns        int64
+	reusedConns     int64
+	staleErrors     int64 // requests that failed on a reused connection
+	serverClosed    int64
+	newConnLat      *phaseReport
+	reusedConnLat   *phaseReport
+	protocols       map[string]int64 // responses by protocol
+	streamsMean     float64          // requests in flight per connection, on average
+	streamsMax      int32
+	quic            *quicReport
+	tls             *tlsReport
+	addresses       map[string]int64 // new connections by address, with the DNS options
+	events          *eventReport
+	ws              wsStats
+	wireIn          int64   // bytes received on the wire
+	wireOut         int64   // bytes sent on the wire
+	compression     float64 // decoded body bytes per body byte on the wire, over http1.1
+	headerIn        float64 // average response header size, over http1.1
+	headerOut       float64 // average request header size, over http1.1
+	// average bytes on the wire of the requests over connections of their
+	// own, each way
+	requestIn, requestOut float64
 }
 
-func (blitz *Blitz) report() {
+// A quicReport describes the QUIC connections of an h3 test
+type quicReport struct {
+	handshakes *phaseReport
+	resumed    int64
+	used0RTT   int64
+	failed     int64
+}
+
+// A tlsReport describes the TLS handshakes with the targets
+type tlsReport struct {
+	full     *phaseReport
+	resumed  *phaseReport
+	failed   int64
+	versions map[string]int64
+	ciphers  map[string]int64
+	alpn     map[string]int64
+	// storm clients that got no session ticket, so did not resume
+	noTicket int64
+}
+
+// An eventReport describes the responses read as streams of events
+type eventReport struct {
+	responses int64
+	count     int64
+	mean      float64 // events per response
+	max       int64
+	first     *phaseReport // time to the first event
+	gap       *phaseReport // time between events
+	stream    *phaseReport // duration of the whole response
+}
+
+// A phaseReport holds the latencies of one phase of the requests, in seconds
+type phaseReport struct {
+	count  int64
+	avgLat float64
+	p50Lat float64
+	p90Lat float64
+	p99Lat float64
+	maxLat float64
+}
+
+func newPhaseReport(h *histogram) *phaseReport {
+	return &phaseReport{
+		count:  h.count,
+		avgLat: h.mean().Seconds(),
+		p50Lat: h.quantile(0.50).Seconds(),
+		p90Lat: h.quantile(0.90).Seconds(),
+		p99Lat: h.quantile(0.99).Seconds(),
+		maxLat: h.max.Seconds(),
+	}
+}
+
+// A nameReport holds the results of the requests sharing a name
+type nameReport struct {
+	name        string
+	requests    int64
+	success     int64
+	netErrors   int64
+	statusCodes map[int]int64
+	errors      map[string]*errorClass
+	checkFails  int64
+	avgLat      float64
+	p50Lat      float64
+	p90Lat      float64
+	p99Lat      float64
+	maxLat      float64
+	rate        float64 // requests per second
+}
+
+// isSuccess tells whether an http status code counts as a successful hit
+// for requests without a status check
+func isSuccess(code int) bool {
+	return code >= 200 && code <= 302
+}
+
+// report prints the results of the load test and returns the exit code
+func (blitz *Blitz) report() int {
 	fmt.Println("\nPreparing report...")
-	report := &report{statusCodes: make(map[int]int), errors: make(map[string]int), graphData: make([]*plot, 0)}
-	quants := quantile.NewTargeted(0.50, 0.99)
-	var (
-		duration float64
-		diff     float64
-		data     *plot
-		errFlag  bool
-	)
-	for {
-		select {
-		case results := <-blitz.results:
-			for _, result := range *results {
-				diff = result.timestamp.Sub(blitz.startTime).Seconds()
-				report.totalRequests++
-				duration = result.duration.Seconds()
-				if result.err != nil {
-					report.errors[result.err.Error()]++
-					report.totalHttpErrors++
-					errFlag = true
-				} else {
-					report.statusCodes[result.statusCode]++
-					if result.statusCode >= 200 && result.statusCode <= 302 {
-						report.totalSuccess++
-						errFlag = false
-					} else {
-						errFlag = true
-					}
-					report.latencies = append(report.latencies, duration)
-					report.totalTimeSum += duration
-					if result.contentLength > 0 {
-						report.totalSize += result.contentLength
-					}
-					if duration > report.maxLat {
-						report.maxLat = duration
-					}
-					quants.Insert(duration)
-				}
-				if outFormat != "" {
-					if errFlag {
-						data = &plot{elapsed: diff, latencySuccess: "0", latencyErr: strconv.FormatFloat(duration, 'f', 5, 64)}
-					} else {
-						data = &plot{elapsed: diff, latencySuccess: strconv.FormatFloat(duration, 'f', 5, 64), latencyErr: "0"}
-					}
-					report.graphData = append(report.graphData, data)
-				}
-			}
-		default:
-			report.totalTime = time.Now().Sub(blitz.startTime).Seconds()
-			report.percentile50Lat = quants.Query(0.50)
-			report.percentile99Lat = quants.Query(0.99)
-			if report.totalTimeSum > 0 {
-				report.avgLat = report.totalTimeSum / float64(len(report.latencies))
+	blitz.stats.Lock()
+	defer blitz.stats.Unlock()
+	now := time.Now()
+	blitz.stats.flush(now)
+	total := blitz.stats.total
+	report := &report{
+		statusCodes:     total.statusCodes,
+		errors:          total.errors,
+		checks:          total.checks,
+		checkFailures:   total.checkFailures,
+		percentile50Lat: total.latency.quantile(0.50).Seconds(),
+		percentile99Lat: total.latency.quantile(0.99).Seconds(),
+		maxLat:          total.latency.max.Seconds(),
+		avgLat:          total.latency.mean().Seconds(),
+		totalTime:       now.Sub(blitz.startTime).Seconds(),
+		totalSize:       total.bytes,
+		totalRequests:   total.requests,
+		totalSuccess:    total.success,
+		totalHttpErrors: total.netErrors,
+		newConns:        total.newConns,
+		reusedConns:     total.reusedConns,
+		staleErrors:     total.staleErrors,
+		newConnLat:      newPhaseReport(total.newConnLatency),
+		reusedConnLat:   newPhaseReport(total.reusedConnLatency),
+		protocols:       total.protocols,
+		streamsMax:      total.streamMax,
+		interval:        blitz.stats.interval.Seconds(),
+		series:          blitz.stats.series,
+	}
+	if total.streamCount > 0 {
+		report.streamsMean = float64(total.streamSum) / float64(total.streamCount)
+	}
+	if qs := blitz.quic; qs != nil {
+		qs.Lock()
+		report.quic = &quicReport{
+			handshakes: newPhaseReport(qs.handshakes),
+			resumed:    qs.resumed,
+			used0RTT:   qs.used0RTT,
+			failed:     qs.failed,
+		}
+		qs.Unlock()
+	}
+	if ts := blitz.tls; ts != nil {
+		ts.Lock()
+		if ts.full.count+ts.resumed.count+ts.failed > 0 {
+			report.tls = &tlsReport{
+				full:     newPhaseReport(ts.full),
+				resumed:  newPhaseReport(ts.resumed),
+				failed:   ts.failed,
+				versions: ts.versions,
+				ciphers:  ts.ciphers,
+				alpn:     ts.alpn,
+				noTicket: ts.ticketless,
 			}
-			print(report)
-			return
 		}
+		ts.Unlock()
+	}
+	if r := targetDNS; r != nil {
+		r.Lock()
+		report.addresses = make(map[string]int64, len(r.dialed))
+		for address, count := range r.dialed {
+			report.addresses[address] = count
+		}
+		r.Unlock()
+	}
+	if blitz.events != "" {
+		report.events = &eventReport{
+			responses: total.eventResponses,
+			count:     total.events,
+			max:       total.eventMax,
+			first:     newPhaseReport(total.firstEvent),
+			gap:       newPhaseReport(total.eventGap),
+			stream:    newPhaseReport(total.eventStream),
+		}
+		if total.eventResponses > 0 {
+			report.events.mean = float64(total.events) / float64(total.eventResponses)
+		}
+	}
+	report.ws = wsStats{
+		open:     atomic.LoadInt64(&blitz.ws.open),
+		maxOpen:  atomic.LoadInt64(&blitz.ws.maxOpen),
+		upgrades: atomic.LoadInt64(&blitz.ws.upgrades),
+		drops:    atomic.LoadInt64(&blitz.ws.drops),
+	}
+	conns := blitz.conns.snapshot()
+	report.serverClosed = conns.serverClosed
+	report.wireIn, report.wireOut = conns.bytesIn, conns.bytesOut
+	if total.headed > 0 {
+		report.headerIn = float64(total.headerIn) / float64(total.headed)
+		report.headerOut = float64(total.headerOut) / float64(total.headed)
+	}
+	if total.wired > 0 {
+		report.requestIn = float64(total.wireIn) / float64(total.wired)
+		report.requestOut = float64(total.wireOut) / float64(total.wired)
+		// only the http1.1 responses have their wire bytes split from those of
+		// other requests on the connection
+		if wireBody := total.wireIn - total.wiredHead; wireBody > 0 {
+			report.compression = float64(total.wiredBody) / float64(wireBody)
+		}
+	}
+	for i, h := range total.phases {
+		report.phases[i] = newPhaseReport(h)
+	}
+	for name, b := range blitz.stats.names {
+		named := &nameReport{
+			name:        name,
+			requests:    b.requests,
+			success:     b.success,
+			netErrors:   b.netErrors,
+			statusCodes: b.statusCodes,
+			errors:      b.errors,
+			checkFails:  b.checkFailures,
+			avgLat:      b.latency.mean().Seconds(),
+			p50Lat:      b.latency.quantile(0.50).Seconds(),
+			p90Lat:      b.latency.quantile(0.90).Seconds(),
+			p99Lat:      b.latency.quantile(0.99).Seconds(),
+			maxLat:      b.latency.max.Seconds(),
+			rate:        float64(b.requests) / report.totalTime,
+		}
+		report.names = append(report.names, named)
 	}
+	sort.Sort(nameReports(report.names))
+	report.thresholds = blitz.stats.evaluate(blitz.thresholds, report.totalTime)
+	report.aborted = blitz.aborted
+	if blitz.samples != nil {
+		report.samplesFile = blitz.samples.write()
+	}
+	print(report)
+
+	if report.aborted != "" {
+		return ExitAborted
+	}
+	for _, result := range report.thresholds {
+		if !result.passed {
+			return ExitThresholdFailed
+		}
+	}
+	return ExitOK
 }
 
-func print(report *report) {
+type nameReports []*nameReport
+
+func (nr nameReports) Len() int           { return len(nr) }
+func (nr nameReports) Swap(i, j int)      { nr[i], nr[j] = nr[j], nr[i] }
+func (nr nameReports) Less(i, j int) bool { return nr[i].name < nr[j].name }
+
+// statusText names a status code. gRPC codes are below 100, out of the
+// way of HTTP ones, and are named as in the gRPC spec.
+func statusText(code int) string {
+	if code < 100 {
+		return codes.Code(code).String()
+	}
+	return strconv.Itoa(code)
+}
+
+// formatCodes lists status codes as code:count pairs in ascending order
+func formatCodes(codes map[int]int64) string {
 	var statusCodes []int
-	for code := range report.statusCodes {
+	for code := range codes {
 		statusCodes = append(statusCodes, code)
 	}
 	sort.Ints(statusCodes)
+	var buffer bytes.Buffer
+	for _, code := range statusCodes {
+		fmt.Fprintf(&buffer, "%s:%d  ", statusText(code), codes[code])
+	}
+	return buffer.String()
+}
 
+// formatProtocols renders the response count of every protocol
+func formatProtocols(protocols map[string]int64) string {
+	var protos []string
+	for proto := range protocols {
+		protos = append(protos, proto)
+	}
+	sort.Strings(protos)
+	var buffer bytes.Buffer
+	for _, proto := range protos {
+		fmt.Fprintf(&buffer, "%s:%d  ", proto, protocols[proto])
+	}
+	return buffer.String()
+}
+
+// errorCategories returns the categories of errors, the most frequent first
+func errorCategories(errors map[string]*errorClass) []string {
+	categories := make([]string, 0, len(errors))
+	for category := range errors {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		ci, cj := errors[categories[i]].count, errors[categories[j]].count
+		return ci > cj || ci == cj && categories[i] < categories[j]
+	})
+	return categories
+}
+
+func print(report *report) {
 	out := &bytes.Buffer{}
 	tabw := tabwriter.NewWriter(out, 0, 8, 3, ' ', tabwriter.StripEscape)
 	fmt.Fprintf(tabw, "----------------------------------------------------------------------------------\n")
@@ -125,28 +365,173 @@ func print(report *report) {
 	fmt.Fprintf(tabw, "Requests\t[success]\t%d hits\n", report.totalSuccess)
 	fmt.Fprintf(tabw, "Availability\t[ratio]\t%3.3f%%\n", float64(report.totalSuccess)*100/float64(report.totalRequests))
 	fmt.Fprintf(tabw, "Network Errors\t[total]\t%d \n", report.totalHttpErrors)
-	fmt.Fprintf(tabw, "Status Codes\t[code:count]\t")
-	for _, code := range statusCodes {
-		fmt.Fprintf(tabw, "%d:%d  ", code, report.statusCodes[code])
-	}
+	fmt.Fprintf(tabw, "Check Failures\t[total]\t%d \n", report.checkFailures)
+	fmt.Fprintf(tabw, "Status Codes\t[code:count]\t%s", formatCodes(report.statusCodes))
 	fmt.Fprintf(tabw, "\nLatencies\t[mean, 50p, 99p, max]\t%3.4fs, %3.4fs, %3.4fs, %3.4fs\n", report.avgLat, report.percentile50Lat, report.percentile99Lat, report.maxLat)
+	for i, phase := range report.phases {
+		if phase.count > 0 {
+			fmt.Fprintf(tabw, "  %s\t[mean, 50p, 90p, 99p, max]\t%3.4fs, %3.4fs, %3.4fs, %3.4fs, %3.4fs\n",
+				phaseNames[i], phase.avgLat, phase.p50Lat, phase.p90Lat, phase.p99Lat, phase.maxLat)
+		}
+	}
+	fmt.Fprintf(tabw, "Connections\t[new, reused, server closed, stale]\t%d, %d, %d, %d\n",
+		report.newConns, report.reusedConns, report.serverClosed, report.staleErrors)
+	fmt.Fprintf(tabw, "  New\t[mean, 50p, 99p, max]\t%3.4fs, %3.4fs, %3.4fs, %3.4fs\n",
+		report.newConnLat.avgLat, report.newConnLat.p50Lat, report.newConnLat.p99Lat, report.newConnLat.maxLat)
+	fmt.Fprintf(tabw, "  Reused\t[mean, 50p, 99p, max]\t%3.4fs, %3.4fs, %3.4fs, %3.4fs\n",
+		report.reusedConnLat.avgLat, report.reusedConnLat.p50Lat, report.reusedConnLat.p99Lat, report.reusedConnLat.maxLat)
+	if q := report.quic; q != nil {
+		fmt.Fprintf(tabw, "QUIC Handshakes\t[total, resumed, 0-RTT, failed]\t%d, %d, %d, %d\n",
+			q.handshakes.count, q.resumed, q.used0RTT, q.failed)
+		fmt.Fprintf(tabw, "  Handshake\t[mean, 50p, 90p, 99p, max]\t%3.4fs, %3.4fs, %3.4fs, %3.4fs, %3.4fs\n",
+			q.handshakes.avgLat, q.handshakes.p50Lat, q.handshakes.p90Lat, q.handshakes.p99Lat, q.handshakes.maxLat)
+	}
+	if t := report.tls; t != nil {
+		fmt.Fprintf(tabw, "TLS Handshakes\t[full, resumed, failed]\t%d, %d, %d\n", t.full.count, t.resumed.count, t.failed)
+		for _, phase := range []struct {
+			name   string
+			report *phaseReport
+		}{{"Full", t.full}, {"Resumed", t.resumed}} {
+			if phase.report.count > 0 {
+				fmt.Fprintf(tabw, "  %s\t[mean, 50p, 90p, 99p, max]\t%3.4fs, %3.4fs, %3.4fs, %3.4fs, %3.4fs\n", phase.name,
+					phase.report.avgLat, phase.report.p50Lat, phase.report.p90Lat, phase.report.p99Lat, phase.report.maxLat)
+			}
+		}
+		fmt.Fprintf(tabw, "  Versions\t[version:count]\t%s\n", formatProtocols(t.versions))
+		fmt.Fprintf(tabw, "  Ciphers\t[suite:count]\t%s\n", formatProtocols(t.ciphers))
+		fmt.Fprintf(tabw, "  ALPN\t[protocol:count]\t%s\n", formatProtocols(t.alpn))
+		if t.noTicket > 0 {
+			fmt.Fprintf(tabw, "  Tickets\t[clients without]\t%d, stopped waiting for session tickets after %d waits of %v in a row without one\n",
+				t.noTicket, ticketMisses, ticketWait)
+		}
+	}
+	if report.addresses != nil {
+		fmt.Fprintf(tabw, "Addresses\t[address:count]\t%s\n", formatProtocols(report.addresses))
+	}
+	if ws := report.ws; ws.upgrades > 0 {
+		fmt.Fprintf(tabw, "WebSockets\t[upgrades, drops, open, max open]\t%d, %d, %d, %d\n",
+			ws.upgrades, ws.drops, ws.open, ws.maxOpen)
+	}
+	if ev := report.events; ev != nil {
+		fmt.Fprintf(tabw, "Events\t[responses, total, mean, max per response]\t%d, %d, %3.2f, %d\n",
+			ev.responses, ev.count, ev.mean, ev.max)
+		for _, phase := range []struct {
+			name   string
+			report *phaseReport
+		}{{"First Event", ev.first}, {"Gap", ev.gap}, {"Stream", ev.stream}} {
+			fmt.Fprintf(tabw, "  %s\t[mean, 50p, 90p, 99p, max]\t%3.4fs, %3.4fs, %3.4fs, %3.4fs, %3.4fs\n", phase.name,
+				phase.report.avgLat, phase.report.p50Lat, phase.report.p90Lat, phase.report.p99Lat, phase.report.maxLat)
+		}
+	}
+	fmt.Fprintf(tabw, "Protocols\t[proto:count]\t%s\n", formatProtocols(report.protocols))
+	fmt.Fprintf(tabw, "Streams\t[mean, max]\t%3.2f, %d in flight per connection\n", report.streamsMean, report.streamsMax)
 	fmt.Fprintf(tabw, "Request Rate\t[success]\t%5.3f hits/sec\n", float64(report.totalSuccess)/report.totalTime)
 	fmt.Fprintf(tabw, "Data Recieved\t[total]\t%4.5f MB\n", float64(report.totalSize)/1048576)
+	fmt.Fprintf(tabw, "Wire Traffic\t[in, out]\t%4.5f MB, %4.5f MB\n", float64(report.wireIn)/1048576, float64(report.wireOut)/1048576)
+	fmt.Fprintf(tabw, "Wire Throughput\t[in, out]\t%4.3f MB/s, %4.3f MB/s\n",
+		float64(report.wireIn)/1048576/report.totalTime, float64(report.wireOut)/1048576/report.totalTime)
+	if report.compression > 0 {
+		fmt.Fprintf(tabw, "Compression\t[decoded/wire body]\t%3.2fx\n", report.compression)
+	}
+	if report.requestIn > 0 {
+		fmt.Fprintf(tabw, "Request Wire\t[in, out]\t%3.0f, %3.0f bytes avg over http1.1\n", report.requestIn, report.requestOut)
+	}
+	if report.headerIn > 0 {
+		fmt.Fprintf(tabw, "Header Overhead\t[response, request]\t%3.0f, %3.0f bytes avg over http1.1, estimated\n", report.headerIn, report.headerOut)
+	}
 	fmt.Fprintf(tabw, "Duration\t[total]\t%3.2f secs\n", report.totalTime)
+	if report.aborted != "" {
+		fmt.Fprintf(tabw, "Aborted\t[reason]\t%s\n", report.aborted)
+	}
+	if report.samplesFile != "" {
+		fmt.Fprintf(tabw, "Sample Failures\t[file]\t%s\n", report.samplesFile)
+	}
 	fmt.Fprintf(tabw, "----------------------------------------------------------------------------------")
 
+	if len(report.names) > 0 {
+		fmt.Fprintln(tabw, "\n\nRequests by name:")
+		fmt.Fprintln(tabw, "Name\tCount\tSuccess\tErrors\tFailed\tStatus Codes\tMean\t50p\t90p\t99p\tMax\tRate")
+		for _, name := range report.names {
+			fmt.Fprintf(tabw, "%s\t%d\t%d\t%d\t%d\t%s\t%3.4fs\t%3.4fs\t%3.4fs\t%3.4fs\t%3.4fs\t%5.3f hits/sec\n",
+				name.name, name.requests, name.success, name.netErrors, name.checkFails, formatCodes(name.statusCodes),
+				name.avgLat, name.p50Lat, name.p90Lat, name.p99Lat, name.maxLat, name.rate)
+		}
+	}
+
+	if len(report.thresholds) > 0 {
+		fmt.Fprintln(tabw, "\n\nThresholds: [threshold]: [actual]: [result]")
+		for _, result := range report.thresholds {
+			verdict := "PASS"
+			if !result.passed {
+				verdict = "FAIL"
+			}
+			fmt.Fprintf(tabw, "%s\t%s\t%s\n", result.threshold.expr, result.threshold.format(result.value), verdict)
+		}
+	}
+
+	if len(report.checks) > 0 {
+		fmt.Fprintln(tabw, "\n\nCheck Failures: [check]: [count]")
+		checkNames := make([]string, 0, len(report.checks))
+		for name := range report.checks {
+			checkNames = append(checkNames, name)
+		}
+		sort.Strings(checkNames)
+		for _, name := range checkNames {
+			fmt.Fprintf(tabw, "%s: [%d]  \n", name, report.checks[name])
+		}
+	}
+
+	if showSeries {
+		fmt.Fprintf(tabw, "\n\nTime series: [%gs intervals]\n", report.interval)
+		fmt.Fprint(tabw, "Elapsed\tRequests\tSuccess\tErrors\tRate\tMean\t50p\t90p\t99p\tMax\tOpen\tNew\tReused\tClosed\tIn\tOut")
+		if report.events != nil {
+			fmt.Fprint(tabw, "\tEvents\tFirst 50p\tFirst 99p\tGap 50p\tGap 99p")
+		}
+		fmt.Fprintln(tabw)
+		for _, stat := range report.series {
+			fmt.Fprintf(tabw, "%3.2fs\t%d\t%d\t%d\t%5.3f hits/sec\t%3.4fs\t%3.4fs\t%3.4fs\t%3.4fs\t%3.4fs\t%d\t%d\t%d\t%d\t%4.3f MB/s\t%4.3f MB/s",
+				stat.elapsed, stat.requests, stat.success, stat.requests-stat.success, stat.rate,
+				stat.mean, stat.p50, stat.p90, stat.p99, stat.max,
+				stat.openConns, stat.newConns, stat.reusedConns, stat.serverClosed,
+				float64(stat.wireIn)/1048576/stat.length, float64(stat.wireOut)/1048576/stat.length)
+			if report.events != nil {
+				fmt.Fprintf(tabw, "\t%d\t%3.4fs\t%3.4fs\t%3.4fs\t%3.4fs",
+					stat.events, stat.firstEventP50, stat.firstEventP99, stat.eventGapP50, stat.eventGapP99)
+			}
+			fmt.Fprintln(tabw)
+		}
+	}
+
 	if len(report.errors) > 0 && showErr {
 		fmt.Fprintln(tabw, "\n\nNetwork Errors: [error]: [count]")
-		for key, count := range report.errors {
-			fmt.Fprintf(tabw, "%s: [%d]  \n", key, count)
+		for _, category := range errorCategories(report.errors) {
+			class := report.errors[category]
+			fmt.Fprintf(tabw, "%s: [%d]  \n", category, class.count)
+			for _, example := range class.examples {
+				fmt.Fprintf(tabw, "    %s\n", example)
+			}
+		}
+		if len(report.names) > 1 {
+			fmt.Fprintln(tabw, "\nNetwork Errors by name: [name]: [error]: [count]")
+			for _, name := range report.names {
+				for _, category := range errorCategories(name.errors) {
+					fmt.Fprintf(tabw, "%s: %s: [%d]  \n", name.name, category, name.errors[category].count)
+				}
+			}
 		}
 	}
 	tabw.Flush()
 	outStr := out.String()
 	fmt.Println(outStr)
 
-	switch outFormat {
-	case "graph":
-		graph(report, outStr)
+	for _, format := range strings.Split(outFormat, ",") {
+		switch strings.TrimSpace(format) {
+		case "graph":
+			graph(report, outStr)
+		case "json":
+			writeJSON(report)
+		case "csv":
+			writeCSV(report)
+		}
 	}
 }
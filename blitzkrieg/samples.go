@@ -0,0 +1,337 @@
+package blitzkrieg
+
+import (
+	"encoding/json"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSampleBody is the number of bytes of a response body kept in a sample
+const maxSampleBody = 4096
+
+// A sample is a failed request kept with what came back, for debugging
+type sample struct {
+	key          string // status code or error category
+	req          *blitzRequest
+	started      time.Time
+	duration     time.Duration
+	resp         *http.Response // nil on transport errors
+	body         []byte         // truncated to maxSampleBody
+	bodySize     int
+	err          error
+	failedChecks []string
+}
+
+// A sampler keeps the first limit failed requests of every status code and
+// error category
+type sampler struct {
+	sync.Mutex
+	limit      int
+	compressed bool // whether requests asked for gzip, for the curl commands
+	counts     map[string]int
+	samples    []*sample
+}
+
+func newSampler(limit int, compressed bool) *sampler {
+	return &sampler{limit: limit, compressed: compressed, counts: make(map[string]int)}
+}
+
+// capture keeps a failed request unless there are enough samples of its kind
+// already. resp and body are only read, the response must be closed.
+func (s *sampler) capture(req *blitzRequest, started time.Time, duration time.Duration,
+	resp *http.Response, body []byte, err error, errClass string, failedChecks []string) {
+	key := errClass
+	if err == nil && resp != nil {
+		key = strconv.Itoa(resp.StatusCode)
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.counts[key] >= s.limit {
+		return
+	}
+	s.counts[key]++
+	sm := &sample{key: key, req: req, started: started, duration: duration, resp: resp,
+		bodySize: len(body), err: err, failedChecks: failedChecks}
+	if len(body) > maxSampleBody {
+		body = body[:maxSampleBody]
+	}
+	sm.body = append([]byte(nil), body...)
+	s.samples = append(s.samples, sm)
+}
+
+// curl returns a command line reproducing the request
+func (s *sampler) curl(req *blitzRequest) string {
+	if req.rpc != nil {
+		return grpcurl(req)
+	}
+	if req.method == "RAW" {
+		return rawCommand(req)
+	}
+	cmd := append([]string{"curl"}, curlTLS()...)
+	cmd = append(cmd, "-i")
+	if u, err := neturl.Parse(req.url); err == nil {
+		if network, socket := unixAddress("tcp", u.Host); network == "unix" {
+			cmd = append(cmd, "--unix-socket", shellQuote(socket))
+		} else if proxy := proxyFor(targetAddress(u)); proxy != nil {
+			switch proxy.Scheme {
+			case proxyHTTPS:
+				if proxyTLS.InsecureSkipVerify {
+					cmd = append(cmd, "--proxy-insecure")
+				} else {
+					cmd = append(cmd, "--proxy-cacert", shellQuote(caFile))
+				}
+				fallthrough
+			case proxyHTTP:
+				cmd = append(cmd, "--proxytunnel")
+			}
+			cmd = append(cmd, "-x", shellQuote(proxy.String()))
+		}
+		if targetDNS != nil && targetDNS.overrides[targetAddress(u)] != nil {
+			var addrs []string
+			for _, ip := range targetDNS.overrides[targetAddress(u)] {
+				addrs = append(addrs, ip.String())
+			}
+			cmd = append(cmd, "--resolve", shellQuote(targetAddress(u)+":"+strings.Join(addrs, ",")))
+		}
+	}
+	if s.compressed {
+		cmd = append(cmd, "--compressed")
+	}
+	if req.method != "GET" {
+		cmd = append(cmd, "-X", shellQuote(req.method))
+	}
+	for _, h := range harHeaders(req.header) {
+		cmd = append(cmd, "-H", shellQuote(h.Name+": "+h.Value))
+	}
+	if req.body != "" {
+		cmd = append(cmd, "--data-binary", shellQuote(req.body))
+	}
+	return strings.Join(append(cmd, shellQuote(req.url)), " ")
+}
+
+// curlTLS returns the curl options matching the TLS options. curl names
+// cipher suites its own way and has no SNI override, so those are left out.
+func curlTLS() []string {
+	var args []string
+	if caFile != "" {
+		args = append(args, "--cacert", shellQuote(caFile))
+	} else {
+		args = append(args, "-k")
+	}
+	if certFile != "" {
+		args = append(args, "--cert", shellQuote(certFile))
+	}
+	if keyFile != "" {
+		args = append(args, "--key", shellQuote(keyFile))
+	}
+	if tlsMin != "" {
+		args = append(args, "--tlsv"+tlsMin)
+	}
+	if tlsMax != "" {
+		args = append(args, "--tls-max", tlsMax)
+	}
+	return args
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// HAR 1.2 (http://www.softwareishard.com/blog/har-12-spec/), with the
+// category, error, failed checks and curl command as custom fields
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Category        string      `json:"_category"`
+	Error           string      `json:"_error,omitempty"`
+	FailedChecks    []string    `json:"_failedChecks,omitempty"`
+	Curl            string      `json:"_curl"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harHeaders lists a header map sorted by name
+func harHeaders(header http.Header) []harNameValue {
+	list := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			list = append(list, harNameValue{name, value})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func (s *sampler) harEntry(sm *sample) *harEntry {
+	ms := float64(sm.duration) / float64(time.Millisecond)
+	entry := &harEntry{
+		StartedDateTime: sm.started.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request: harRequest{
+			Method:      sm.req.method,
+			URL:         sm.req.url,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(sm.req.header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(sm.req.body),
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings:      harTimings{Wait: ms},
+		Category:     sm.key,
+		FailedChecks: sm.failedChecks,
+		Curl:         s.curl(sm.req),
+	}
+	if u, err := neturl.Parse(sm.req.url); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{name, value})
+			}
+		}
+	}
+	if sm.req.method != "RAW" {
+		// the requests are sent with the configured header map, the rest is
+		// written by the transports
+		entry.Request.Comment = "configured headers, without those the transport adds: Host, User-Agent, " +
+			"Content-Length, Accept-Encoding, h2 pseudo-headers and WebSocket or gRPC ones"
+	}
+	if sm.req.body != "" {
+		entry.Request.PostData = &harPostData{MimeType: sm.req.header.Get("Content-Type"), Text: sm.req.body}
+	}
+	if sm.err != nil {
+		entry.Error = sm.err.Error()
+	}
+	if resp := sm.resp; resp != nil {
+		entry.Response.Status = resp.StatusCode
+		entry.Response.StatusText = strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)))
+		entry.Response.HTTPVersion = resp.Proto
+		entry.Response.Headers = harHeaders(resp.Header)
+		entry.Response.RedirectURL = resp.Header.Get("Location")
+		entry.Response.BodySize = sm.bodySize
+		entry.Response.Content = harContent{
+			Size:     sm.bodySize,
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     string(sm.body),
+		}
+	} else if sm.bodySize > 0 {
+		// the WebSocket reply that failed the checks
+		entry.Response.BodySize = sm.bodySize
+		entry.Response.Content = harContent{Size: sm.bodySize, Text: string(sm.body)}
+	}
+	if len(sm.body) < sm.bodySize {
+		entry.Response.Content.Comment = "truncated to " + strconv.Itoa(maxSampleBody) + " bytes"
+	}
+	return entry
+}
+
+// write saves the samples as a HAR file and returns its name, or an empty
+// string when no request failed
+func (s *sampler) write() string {
+	s.Lock()
+	defer s.Unlock()
+	if len(s.samples) == 0 {
+		return ""
+	}
+	out := &harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "blitz", Version: VERSION},
+	}}
+	for _, sm := range s.samples {
+		out.Log.Entries = append(out.Log.Entries, s.harEntry(sm))
+	}
+
+	fileName := time.Now().Format("2006-01-02-15-04-05-samples.har")
+	fo, err := os.Create(fileName)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := fo.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	encoder := json.NewEncoder(fo)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		panic(err)
+	}
+	return fileName
+}
@@ -0,0 +1,368 @@
+package blitzkrieg
+
+import (
+	"bufio"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A bucket aggregates the results that fall into it. Buckets never hold on
+// to individual results, so their size does not grow with the request count.
+type bucket struct {
+	requests      int64
+	success       int64
+	netErrors     int64
+	bytes         int64
+	statusCodes   map[int]int64
+	errors        map[string]*errorClass // transport errors by category
+	checks        map[string]int64       // failures by check name
+	checkFailures int64                  // responses that failed at least one check
+	latency       *histogram             // latencies of requests that got a response
+	phases        [numPhases]*histogram
+	successTime   time.Duration // summed latency of successful requests
+	failTime      time.Duration // summed latency of failed requests
+	newConns      int64
+	reusedConns   int64
+	staleErrors   int64 // failures on reused connections, the server having dropped them
+
+	newConnLatency    *histogram
+	reusedConnLatency *histogram
+
+	bodyBytes int64 // decoded body bytes of every response
+	headed    int64 // http1.1 responses, whose header sizes are known
+	headerIn  int64
+	headerOut int64
+	wired     int64 // responses over connections of their own, whose bytes on the wire are known
+	wireIn    int64
+	wireOut   int64
+	wiredBody int64 // decoded body bytes of the wired responses
+	wiredHead int64 // header bytes of the wired responses
+
+	protocols   map[string]int64 // responses by protocol
+	streamCount int64            // requests that got a connection
+	streamSum   int64            // requests in flight on their connection, summed
+	streamMax   int32
+
+	eventResponses int64      // responses read as events
+	events         int64      // events in those responses
+	eventMax       int64      // most events in a single response
+	firstEvent     *histogram // from the start of the request to the first event
+	eventGap       *histogram // between consecutive events of a response
+	eventStream    *histogram // latencies of the responses read as events
+}
+
+func newBucket() *bucket {
+	b := &bucket{
+		statusCodes: make(map[int]int64),
+		errors:      make(map[string]*errorClass),
+		checks:      make(map[string]int64),
+		latency:     newHistogram(),
+		protocols:   make(map[string]int64),
+
+		newConnLatency:    newHistogram(),
+		reusedConnLatency: newHistogram(),
+
+		firstEvent:  newHistogram(),
+		eventGap:    newHistogram(),
+		eventStream: newHistogram(),
+	}
+	for i := range b.phases {
+		b.phases[i] = newHistogram()
+	}
+	return b
+}
+
+// newNameBucket returns a bucket for the requests of a single name. It only
+// keeps the latency histogram: the phase, connection and event ones are left
+// to the totals, so that every name costs 8KB rather than a dozen times that.
+func newNameBucket() *bucket {
+	return &bucket{
+		statusCodes: make(map[int]int64),
+		errors:      make(map[string]*errorClass),
+		checks:      make(map[string]int64),
+		latency:     newHistogram(),
+		protocols:   make(map[string]int64),
+	}
+}
+
+// add aggregates a single result into the bucket
+func (b *bucket) add(result *blitzResult) {
+	b.requests++
+	if result.streams > 0 {
+		b.streamCount++
+		b.streamSum += int64(result.streams)
+		if result.streams > b.streamMax {
+			b.streamMax = result.streams
+		}
+	}
+	for i, d := range result.phases {
+		if d > 0 {
+			b.phases[i].record(d)
+		}
+	}
+	if result.newConn {
+		b.newConns++
+	} else if result.reused {
+		b.reusedConns++
+	}
+	if result.err != nil {
+		class, ok := b.errors[result.errClass]
+		if !ok {
+			class = &errorClass{}
+			b.errors[result.errClass] = class
+		}
+		class.add(result.err)
+		b.netErrors++
+		b.failTime += result.duration
+		if result.reused {
+			b.staleErrors++
+		}
+		return
+	}
+	// WebSocket messages have no status, gRPC calls have OK as 0
+	if result.statusCode != 0 || result.proto == protoGRPC {
+		b.statusCodes[result.statusCode]++
+	}
+	b.protocols[result.proto]++
+	b.latency.record(result.duration)
+	b.bodyBytes += result.bodySize
+	if result.headerIn > 0 {
+		b.headed++
+		b.headerIn += result.headerIn
+		b.headerOut += result.headerOut
+	}
+	if result.wireIn > 0 {
+		b.wired++
+		b.wireIn += result.wireIn
+		b.wireOut += result.wireOut
+		b.wiredBody += result.bodySize
+		b.wiredHead += result.headerIn
+	}
+	if result.newConn {
+		b.newConnLatency.record(result.duration)
+	} else if result.reused {
+		b.reusedConnLatency.record(result.duration)
+	}
+	b.bytes += result.contentLength
+	if ev := result.events; ev != nil {
+		b.eventResponses++
+		b.events += ev.count
+		if ev.count > b.eventMax {
+			b.eventMax = ev.count
+		}
+		if ev.count > 0 {
+			b.firstEvent.record(ev.first)
+		}
+		for _, gap := range ev.gaps {
+			b.eventGap.record(gap)
+		}
+		b.eventStream.record(result.duration)
+	}
+	for _, name := range result.failedChecks {
+		b.checks[name]++
+	}
+	if len(result.failedChecks) > 0 {
+		b.checkFailures++
+	}
+	if result.success {
+		b.success++
+		b.successTime += result.duration
+	} else {
+		b.failTime += result.duration
+	}
+}
+
+// An intervalStat summarises an interval bucket once it is closed
+type intervalStat struct {
+	elapsed      float64 // seconds from the start of the test to the start of the interval
+	length       float64 // length of the interval in seconds, shorter for the last one
+	requests     int64
+	success      int64
+	netErrors    int64
+	bytes        int64
+	rate         float64 // requests per second
+	mean         float64
+	meanSuccess  float64
+	meanFail     float64
+	p50          float64
+	p90          float64
+	p99          float64
+	max          float64
+	newConns     int64
+	reusedConns  int64
+	staleErrors  int64
+	wireIn       int64
+	wireOut      int64
+	openConns    int64 // connections open when the interval closed
+	serverClosed int64 // connections the server closed during the interval
+
+	events        int64
+	firstEventP50 float64
+	firstEventP99 float64
+	eventGapP50   float64
+	eventGapP99   float64
+}
+
+func (b *bucket) summarise(elapsed float64, length float64) *intervalStat {
+	stat := &intervalStat{
+		elapsed:   elapsed,
+		length:    length,
+		requests:  b.requests,
+		success:   b.success,
+		netErrors: b.netErrors,
+		bytes:     b.bytes,
+		mean:      b.latency.mean().Seconds(),
+		p50:       b.latency.quantile(0.50).Seconds(),
+		p90:       b.latency.quantile(0.90).Seconds(),
+		p99:       b.latency.quantile(0.99).Seconds(),
+		max:       b.latency.max.Seconds(),
+
+		newConns:    b.newConns,
+		reusedConns: b.reusedConns,
+		staleErrors: b.staleErrors,
+
+		events:        b.events,
+		firstEventP50: b.firstEvent.quantile(0.50).Seconds(),
+		firstEventP99: b.firstEvent.quantile(0.99).Seconds(),
+		eventGapP50:   b.eventGap.quantile(0.50).Seconds(),
+		eventGapP99:   b.eventGap.quantile(0.99).Seconds(),
+	}
+	if length > 0 {
+		stat.rate = float64(b.requests) / length
+	}
+	if b.success > 0 {
+		stat.meanSuccess = b.successTime.Seconds() / float64(b.success)
+	}
+	if failed := b.requests - b.success; failed > 0 {
+		stat.meanFail = b.failTime.Seconds() / float64(failed)
+	}
+	return stat
+}
+
+// stats aggregates the results of a load test on the fly. Only the totals
+// and the running interval are kept as buckets; closed intervals are reduced
+// to an intervalStat, so memory does not grow with the number of requests.
+// It still grows by an intervalStat of a few hundred bytes per interval, a
+// day long test with the default interval keeps some 20MB of series.
+type stats struct {
+	sync.Mutex
+	start    time.Time
+	interval time.Duration
+	total    *bucket
+	names    map[string]*bucket // totals per request name
+	current  *bucket
+	index    int             // index of the current interval
+	series   []*intervalStat // closed intervals
+	rawLog   *bufio.Writer   // optional per request log
+
+	conns     *connStats
+	lastConns connStats // connection counters when the current interval began
+
+	aborts   []*abortCondition
+	abort    func(reason string) // called once when an abort condition is met
+	aborting bool
+}
+
+func newStats(interval time.Duration, conns *connStats) *stats {
+	return &stats{
+		interval: interval,
+		conns:    conns,
+		total:    newBucket(),
+		names:    make(map[string]*bucket),
+		current:  newBucket(),
+		series:   make([]*intervalStat, 0),
+	}
+}
+
+// begin marks the start of the test
+func (s *stats) begin(start time.Time) {
+	s.Lock()
+	s.start = start
+	s.Unlock()
+}
+
+// add aggregates a result into the totals and the running interval
+func (s *stats) add(result *blitzResult) {
+	s.Lock()
+	defer s.Unlock()
+	s.advance(result.timestamp)
+	s.total.add(result)
+	s.current.add(result)
+	named, ok := s.names[result.name]
+	if !ok {
+		named = newNameBucket()
+		s.names[result.name] = named
+	}
+	named.add(result)
+	s.stop(s.checkResult(result))
+	if s.rawLog != nil {
+		errStr := ""
+		if result.err != nil {
+			errStr = result.err.Error()
+		}
+		fmt.Fprintf(s.rawLog, "%.6f\t%s\t%d\t%.6f\t%d\t%s\n", result.timestamp.Sub(s.start).Seconds(),
+			result.name, result.statusCode, result.duration.Seconds(), result.contentLength, errStr)
+	}
+}
+
+// tick closes the running interval if its time is up, even when no results arrive
+func (s *stats) tick(now time.Time) {
+	s.Lock()
+	s.advance(now)
+	s.Unlock()
+}
+
+// advance closes every interval that ended before t. Results that arrive
+// late for an interval that is already closed are counted in the running one.
+func (s *stats) advance(t time.Time) {
+	index := int(t.Sub(s.start) / s.interval)
+	for s.index < index {
+		s.closeInterval(s.interval)
+	}
+}
+
+func (s *stats) closeInterval(length time.Duration) {
+	elapsed := time.Duration(s.index) * s.interval
+	stat := s.current.summarise(elapsed.Seconds(), length.Seconds())
+	conns := s.conns.snapshot()
+	stat.openConns = conns.open
+	stat.serverClosed = conns.serverClosed - s.lastConns.serverClosed
+	stat.wireIn = conns.bytesIn - s.lastConns.bytesIn
+	stat.wireOut = conns.bytesOut - s.lastConns.bytesOut
+	s.lastConns = conns
+	s.series = append(s.series, stat)
+	s.stop(s.checkInterval(s.current, elapsed, elapsed+length))
+	s.current = newBucket()
+	s.index++
+}
+
+// flush closes the running interval at now so that the series covers the
+// whole test. It must be called with the lock held.
+func (s *stats) flush(now time.Time) {
+	s.advance(now)
+	if length := now.Sub(s.start) - time.Duration(s.index)*s.interval; s.current.requests > 0 && length > 0 {
+		s.closeInterval(length)
+	}
+	if s.rawLog != nil {
+		s.rawLog.Flush()
+	}
+}
+
+// collect drains the results channel into blitz.stats until it is closed
+func (blitz *Blitz) collect() {
+	ticker := time.NewTicker(blitz.stats.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case result, ok := <-blitz.results:
+			if !ok {
+				close(blitz.collected)
+				return
+			}
+			blitz.stats.add(result)
+		case now := <-ticker.C:
+			blitz.stats.tick(now)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package blitzkrieg
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	neturl "net/url"
+	"time"
+)
+
+// checkStorm tells why req cannot be part of a handshake storm, if it cannot
+func checkStorm(req *blitzRequest) error {
+	u, err := neturl.Parse(req.url)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "https", "wss", "grpcs":
+		return nil
+	}
+	return fmt.Errorf("%s: handshake storms need https, wss or grpcs URLs", req.url)
+}
+
+// ticketWait bounds how long a storm connection waits for a session ticket
+// after its handshake, ticketMisses is how many waits in a row have to time
+// out for a client to stop waiting
+const (
+	ticketWait   = 100 * time.Millisecond
+	ticketMisses = 3
+)
+
+// A ticketCache is a session cache that tells when a session is put in it.
+// TLS 1.3 servers send their session tickets after the handshake, so a storm
+// that resumes sessions waits for them before closing the connection. Once
+// ticketMisses waits in a row time out, the server is taken not to send any
+// and the client no longer waits; a slow ticket now and then does not stop
+// it.
+type ticketCache struct {
+	tls.ClientSessionCache
+	put    chan struct{}
+	misses int // waits timed out since the last ticket
+}
+
+func (tc *ticketCache) Put(key string, cs *tls.ClientSessionState) {
+	tc.ClientSessionCache.Put(key, cs)
+	select {
+	case tc.put <- struct{}{}:
+	default:
+	}
+}
+
+// stormTLS returns the TLS configuration of a client of a handshake storm
+func stormTLS() *tls.Config {
+	cfg := clientTLS()
+	if cfg.ClientSessionCache != nil {
+		cfg.ClientSessionCache = &ticketCache{ClientSessionCache: cfg.ClientSessionCache, put: make(chan struct{}, 1)}
+	}
+	return cfg
+}
+
+// handshake is a request of a handshake storm: it opens a new connection to
+// the host of req and closes it once the TLS handshake is done, without
+// sending anything over it. It offers the ALPN protocols the request would
+// be sent with.
+func (blitz *Blitz) handshake(req *blitzRequest, cfg *tls.Config) {
+	u, _ := neturl.Parse(req.url)
+	cfg = tlsFor(cfg, u.Hostname()).Clone()
+	cfg.NextProtos = []string{"http/1.1"}
+	if u.Scheme == "grpcs" || u.Scheme == "https" && blitz.proto == protoH2 {
+		cfg.NextProtos = []string{"h2"}
+	}
+
+	timer := &phaseTimer{}
+	ctx := httptrace.WithClientTrace(context.Background(), timer.trace())
+	s := time.Now()
+	conn, err := blitz.dial(ctx, "tcp", targetAddress(u))
+	var proxyTime time.Duration
+	if err == nil {
+		proxyTime = blitzConnOf(conn).proxy
+		conn, err = blitz.tlsHandshake(ctx, conn, cfg)
+	}
+	end := time.Now()
+	result := &blitzResult{
+		name:      req.name,
+		duration:  end.Sub(s),
+		phases:    timer.finish(end),
+		newConn:   err == nil,
+		timestamp: end,
+	}
+	result.phases[phaseProxy] = proxyTime
+	if err != nil {
+		result.err, result.errClass = err, classifyError(err)
+		if blitz.samples != nil {
+			blitz.samples.capture(req, s, result.duration, nil, nil, result.err, result.errClass, nil)
+		}
+	} else {
+		state := conn.(*tls.Conn).ConnectionState()
+		result.success = true
+		result.proto = tls.VersionName(state.Version)
+		if tc, ok := cfg.ClientSessionCache.(*ticketCache); ok && tc.misses < ticketMisses && state.Version == tls.VersionTLS13 && !state.DidResume {
+			// reading processes the ticket, but only returns with data or an error
+			select {
+			case <-tc.put:
+			default:
+			}
+			conn.SetReadDeadline(time.Now().Add(ticketWait))
+			go conn.Read(make([]byte, 1))
+			select {
+			case <-tc.put:
+				tc.misses = 0
+			case <-time.After(ticketWait):
+				if tc.misses++; tc.misses == ticketMisses {
+					blitz.tls.noTickets()
+				}
+			}
+		}
+		conn.Close()
+	}
+	blitz.results <- result
+}
@@ -0,0 +1,147 @@
+package blitzkrieg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exit codes of blitz
+const (
+	ExitOK              = 0
+	ExitThresholdFailed = 1 // at least one threshold did not hold
+	ExitConfigError     = 2 // bad options or request file
+	ExitAborted         = 3 // the run was interrupted or aborted
+)
+
+// A threshold is a pass/fail condition on a metric of the whole test, or of
+// the requests with a given name when prefixed with [name]:
+//
+//	p99<300ms  mean<=1s  max<2s  error_rate<0.5%  rps>1000  [login]p95<500ms
+//
+// Latencies are mean, max or pNN; error_rate is the share of requests that
+// did not succeed and rps the number of requests per second.
+type threshold struct {
+	expr     string
+	name     string // request name, empty for the whole test
+	metric   string
+	op       string
+	value    float64 // seconds for latencies
+	quantile float64 // of pNN latencies, between 0 and 1
+}
+
+var (
+	thresholdExpr = regexp.MustCompile(`^\s*(?:\[([^\]]*)\])?\s*([a-z_]+|p\d+(?:\.\d+)?)\s*(<=|>=|<|>)\s*(\S+)\s*$`)
+	quantileExpr  = regexp.MustCompile(`^p(\d+(?:\.\d+)?)$`)
+)
+
+// parseThreshold parses a threshold expression
+func parseThreshold(expr string) (*threshold, error) {
+	m := thresholdExpr.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid threshold: %s", expr)
+	}
+	t := &threshold{expr: strings.TrimSpace(expr), name: m[1], metric: m[2], op: m[3]}
+	var err error
+	if m := quantileExpr.FindStringSubmatch(t.metric); m != nil {
+		if t.quantile, err = strconv.ParseFloat(m[1], 64); err != nil || t.quantile > 100 {
+			return nil, fmt.Errorf("invalid percentile in threshold: %s", expr)
+		}
+		t.quantile /= 100
+	}
+	switch {
+	case t.isLatency():
+		var d time.Duration
+		d, err = time.ParseDuration(m[4])
+		t.value = d.Seconds()
+	case t.metric == "error_rate":
+		if strings.HasSuffix(m[4], "%") {
+			t.value, err = strconv.ParseFloat(strings.TrimSuffix(m[4], "%"), 64)
+			t.value /= 100
+		} else {
+			t.value, err = strconv.ParseFloat(m[4], 64)
+		}
+	case t.metric == "rps":
+		t.value, err = strconv.ParseFloat(m[4], 64)
+	default:
+		return nil, fmt.Errorf("unknown metric in threshold: %s", expr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value in threshold: %s", expr)
+	}
+	return t, nil
+}
+
+func (t *threshold) isLatency() bool {
+	return t.metric == "mean" || t.metric == "max" || quantileExpr.MatchString(t.metric)
+}
+
+// measure returns the value of the metric over b, elapsed being the length
+// of the test in seconds
+func (t *threshold) measure(b *bucket, elapsed float64) float64 {
+	switch t.metric {
+	case "mean":
+		return b.latency.mean().Seconds()
+	case "max":
+		return b.latency.max.Seconds()
+	case "error_rate":
+		if b.requests == 0 {
+			return 0
+		}
+		return float64(b.requests-b.success) / float64(b.requests)
+	case "rps":
+		return float64(b.requests) / elapsed
+	}
+	return b.latency.quantile(t.quantile).Seconds()
+}
+
+// holds tells whether value satisfies the threshold
+func (t *threshold) holds(value float64) bool {
+	switch t.op {
+	case "<":
+		return value < t.value
+	case "<=":
+		return value <= t.value
+	case ">":
+		return value > t.value
+	}
+	return value >= t.value
+}
+
+// format renders a value of the threshold's metric
+func (t *threshold) format(value float64) string {
+	switch {
+	case t.isLatency():
+		return fmt.Sprintf("%3.4fs", value)
+	case t.metric == "error_rate":
+		return fmt.Sprintf("%3.3f%%", value*100)
+	}
+	return fmt.Sprintf("%5.3f", value)
+}
+
+// A thresholdResult is the outcome of a threshold at the end of the test
+type thresholdResult struct {
+	threshold *threshold
+	value     float64
+	passed    bool
+}
+
+// evaluate checks every threshold against the stats. A threshold on a name
+// that never ran fails.
+func (s *stats) evaluate(thresholds []*threshold, elapsed float64) (results []*thresholdResult) {
+	for _, t := range thresholds {
+		b := s.total
+		if t.name != "" {
+			b = s.names[t.name]
+		}
+		result := &thresholdResult{threshold: t}
+		if b != nil {
+			result.value = t.measure(b, elapsed)
+			result.passed = t.holds(result.value)
+		}
+		results = append(results, result)
+	}
+	return
+}
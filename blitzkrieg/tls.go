@@ -0,0 +1,162 @@
+package blitzkrieg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tlsVersions names the TLS versions -tlsmin and -tlsmax take
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// targetTLS is the TLS configuration of the connections to the targets,
+// from the TLS options. Certificates are only verified against a CA bundle.
+var targetTLS = &tls.Config{InsecureSkipVerify: true}
+
+// proxyTLS is the TLS configuration of the connections to https proxies,
+// which are verified against the CA bundle too
+var proxyTLS = &tls.Config{InsecureSkipVerify: true}
+
+// loadTLS sets targetTLS from the TLS options
+func loadTLS() error {
+	cfg := &tls.Config{InsecureSkipVerify: true, ServerName: sni}
+	if certFile != "" {
+		keyPath := keyFile
+		if keyPath == "" {
+			keyPath = certFile
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyPath)
+		if err != nil {
+			return err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	} else if keyFile != "" {
+		return fmt.Errorf("a key needs a certificate")
+	}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s has no PEM certificates", caFile)
+		}
+		cfg.InsecureSkipVerify = false
+	}
+	for _, v := range []struct {
+		name    string
+		version *uint16
+	}{{tlsMin, &cfg.MinVersion}, {tlsMax, &cfg.MaxVersion}} {
+		if v.name == "" {
+			continue
+		}
+		version, ok := tlsVersions[v.name]
+		if !ok {
+			return fmt.Errorf("unknown TLS version %s, one of 1.0, 1.1, 1.2 or 1.3", v.name)
+		}
+		*v.version = version
+	}
+	if cfg.MinVersion != 0 && cfg.MaxVersion != 0 && cfg.MinVersion > cfg.MaxVersion {
+		return fmt.Errorf("TLS %s is above TLS %s", tlsMin, tlsMax)
+	}
+	if ciphers != "" {
+		ids := make(map[string]uint16)
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			ids[suite.Name] = suite.ID
+		}
+		for _, name := range strings.Split(ciphers, ",") {
+			id, ok := ids[strings.TrimSpace(name)]
+			if !ok {
+				return fmt.Errorf("unknown cipher suite %s", name)
+			}
+			cfg.CipherSuites = append(cfg.CipherSuites, id)
+		}
+	}
+	targetTLS = cfg
+	proxyTLS = &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify, RootCAs: cfg.RootCAs}
+	return nil
+}
+
+// clientTLS returns the TLS configuration of a simulated client, with a
+// session cache of its own when its new connections resume TLS sessions
+func clientTLS() *tls.Config {
+	cfg := targetTLS.Clone()
+	if tlsResume {
+		cfg.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	return cfg
+}
+
+// tlsFor returns cfg for a connection to host, which it names for SNI and
+// verification unless the SNI is set
+func tlsFor(cfg *tls.Config, host string) *tls.Config {
+	if cfg.ServerName != "" {
+		return cfg
+	}
+	cfg = cfg.Clone()
+	cfg.ServerName = host
+	return cfg
+}
+
+// tlsStats follows the TLS handshakes with the targets: how long the full
+// and the resumed ones took, how many failed and what they negotiated
+type tlsStats struct {
+	sync.Mutex
+	full     *histogram
+	resumed  *histogram
+	failed   int64
+	versions map[string]int64
+	ciphers  map[string]int64
+	alpn     map[string]int64 // "none" when no protocol was negotiated
+	// storm clients that got no session ticket to resume with
+	ticketless int64
+}
+
+func newTLSStats() *tlsStats {
+	return &tlsStats{
+		full:     newHistogram(),
+		resumed:  newHistogram(),
+		versions: make(map[string]int64),
+		ciphers:  make(map[string]int64),
+		alpn:     make(map[string]int64),
+	}
+}
+
+// noTickets counts a storm client that got no session ticket
+func (ts *tlsStats) noTickets() {
+	ts.Lock()
+	ts.ticketless++
+	ts.Unlock()
+}
+
+// record counts a handshake that took d, or failed with err
+func (ts *tlsStats) record(state tls.ConnectionState, d time.Duration, err error) {
+	ts.Lock()
+	defer ts.Unlock()
+	if err != nil {
+		ts.failed++
+		return
+	}
+	if state.DidResume {
+		ts.resumed.record(d)
+	} else {
+		ts.full.record(d)
+	}
+	ts.versions[tls.VersionName(state.Version)]++
+	ts.ciphers[tls.CipherSuiteName(state.CipherSuite)]++
+	if state.NegotiatedProtocol != "" {
+		ts.alpn[state.NegotiatedProtocol]++
+	} else {
+		ts.alpn["none"]++
+	}
+}
@@ -0,0 +1,157 @@
+package blitzkrieg
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The phases of a request that are timed through httptrace. DNS, connect,
+// proxy and TLS only happen on new connections; through a proxy, connect is
+// to the proxy and proxy is the time it took to open the tunnel. TTFB runs
+// from the request being written to the first response byte and transfer
+// from there to the end of the body. A pipelined request is blocked, once
+// written, until the responses to the requests ahead of it are read; its
+// TTFB only starts then.
+const (
+	phaseDNS = iota
+	phaseConnect
+	phaseProxy
+	phaseTLS
+	phaseBlocked
+	phaseTTFB
+	phaseTransfer
+	numPhases
+)
+
+var phaseNames = [numPhases]string{"DNS", "Connect", "Proxy", "TLS", "Blocked", "TTFB", "Transfer"}
+
+// A phaseTimer collects the phase timings of a single request. The
+// transport may call the hooks from its dialing goroutine, hence the lock.
+type phaseTimer struct {
+	sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	phases       [numPhases]time.Duration
+	gotConn      bool       // whether the request got a connection at all
+	reused       bool       // whether the request went over a kept-alive connection
+	quic         *quicStats // looks up QUIC connections, with h3
+	inflight     *int32     // in-flight counter of the connection, until the request is released
+	streams      int32      // requests in flight on the connection, this one included
+	// with http1.1 a connection carries one request at a time, its bytes
+	// from the time the request got it are the ones of the request
+	serial            bool
+	wire              *BlitzConn
+	wireIn0, wireOut0 int64
+}
+
+// trace returns the hooks that fill in the timer
+func (t *phaseTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.Lock()
+			t.gotConn = true
+			t.reused = info.Reused
+			if conn := blitzConnOf(info.Conn); conn != nil {
+				if !info.Reused {
+					t.phases[phaseProxy] = conn.proxy
+				}
+				t.inflight = &conn.streams
+				if t.serial {
+					t.wire = conn
+					t.wireIn0, t.wireOut0 = atomic.LoadInt64(&conn.bytesIn), atomic.LoadInt64(&conn.bytesOut)
+				}
+			} else if t.quic != nil {
+				t.inflight = t.quic.inflight(info.Conn)
+			}
+			if t.inflight != nil {
+				t.streams = atomic.AddInt32(t.inflight, 1)
+			}
+			t.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.Lock()
+			t.dnsStart = time.Now()
+			t.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.Lock()
+			t.phases[phaseDNS] = time.Since(t.dnsStart)
+			t.Unlock()
+		},
+		ConnectStart: func(network, addr string) {
+			t.Lock()
+			t.connectStart = time.Now()
+			t.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.Lock()
+			if err == nil {
+				t.phases[phaseConnect] = time.Since(t.connectStart)
+			}
+			t.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.Lock()
+			t.tlsStart = time.Now()
+			t.Unlock()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			t.Lock()
+			if err == nil {
+				t.phases[phaseTLS] = time.Since(t.tlsStart)
+			}
+			t.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.Lock()
+			t.wroteRequest = time.Now()
+			t.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.Lock()
+			t.firstByte = time.Now()
+			if !t.wroteRequest.IsZero() {
+				t.phases[phaseTTFB] = t.firstByte.Sub(t.wroteRequest)
+			}
+			t.Unlock()
+		},
+	}
+}
+
+// finish closes the transfer phase at end and returns the timings. A zero
+// duration means the phase did not happen.
+func (t *phaseTimer) finish(end time.Time) [numPhases]time.Duration {
+	t.Lock()
+	defer t.Unlock()
+	if !t.firstByte.IsZero() {
+		t.phases[phaseTransfer] = end.Sub(t.firstByte)
+	}
+	return t.phases
+}
+
+// wireBytes returns the bytes of the request on the wire each way, 0 unless
+// its connection carries one request at a time
+func (t *phaseTimer) wireBytes() (in int64, out int64) {
+	t.Lock()
+	defer t.Unlock()
+	if t.wire == nil {
+		return 0, 0
+	}
+	return atomic.LoadInt64(&t.wire.bytesIn) - t.wireIn0, atomic.LoadInt64(&t.wire.bytesOut) - t.wireOut0
+}
+
+// release takes the request off its connection once the body has been read
+func (t *phaseTimer) release() {
+	t.Lock()
+	defer t.Unlock()
+	if t.inflight != nil {
+		atomic.AddInt32(t.inflight, -1)
+		t.inflight = nil
+	}
+}
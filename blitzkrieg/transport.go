@@ -0,0 +1,125 @@
+package blitzkrieg
+
+import (
+	"context"
+	"crypto/tls"
+	"golang.org/x/net/http2"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// Protocols blitz can speak
+const (
+	protoHTTP1 = "http1.1"
+	protoH2    = "h2"  // HTTP/2 over TLS, negotiated through ALPN
+	protoH2C   = "h2c" // HTTP/2 over cleartext TCP, with prior knowledge
+)
+
+// dialer returns the dialer of the connections to the targets
+func (blitz *Blitz) dialer() *net.Dialer {
+	return &net.Dialer{Timeout: time.Duration(blitz.connectTimeout) * time.Millisecond}
+}
+
+// dial opens a connection to address, or to the Unix socket it stands for,
+// through the proxy it goes through if any, and wraps it in a BlitzConn,
+// which emulates the network conditions if they are set
+func (blitz *Blitz) dial(ctx context.Context, network string, address string) (net.Conn, error) {
+	conn, proxyTime, err := dialTarget(ctx, blitz.dialer(), network, address)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Duration(blitz.readTimeout) * time.Millisecond))
+	conn.SetWriteDeadline(time.Now().Add(time.Duration(blitz.writeTimeout) * time.Millisecond))
+
+	bConn := &BlitzConn{Conn: conn, readTimeout: time.Duration(blitz.readTimeout) * time.Millisecond, writeTimeout: time.Duration(blitz.writeTimeout) * time.Millisecond, stats: blitz.conns, proxy: proxyTime}
+	if emulated != nil {
+		bConn.emulate(emulated)
+	}
+	blitz.conns.opened()
+	return bConn, nil
+}
+
+// transport returns a round tripper holding a single connection per host.
+// The streams of a client that share a transport queue for the connection
+// with http1.1 and are multiplexed over it with h2, h2c and h3.
+func (blitz *Blitz) transport() http.RoundTripper {
+	tlsConfig := clientTLS()
+	switch blitz.proto {
+	case protoH3:
+		return blitz.h3Transport()
+	case protoH2:
+		return &http2.Transport{
+			TLSClientConfig:            tlsConfig,
+			DisableCompression:         !blitz.gzip,
+			StrictMaxConcurrentStreams: true,
+			DialTLSContext: func(ctx context.Context, network string, address string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := blitz.dial(ctx, network, address)
+				if err != nil {
+					return nil, err
+				}
+				return blitz.tlsHandshake(ctx, conn, cfg)
+			},
+		}
+	case protoH2C:
+		return &http2.Transport{
+			AllowHTTP:                  true,
+			DisableCompression:         !blitz.gzip,
+			StrictMaxConcurrentStreams: true,
+			DialTLSContext: func(ctx context.Context, network string, address string, cfg *tls.Config) (net.Conn, error) {
+				return blitz.dial(ctx, network, address)
+			},
+		}
+	}
+	tlsConfig.NextProtos = []string{"http/1.1"}
+	return &http.Transport{
+		DisableKeepAlives:  !blitz.keepAlive,
+		DisableCompression: !blitz.gzip,
+		MaxConnsPerHost:    1,
+		DialContext:        blitz.dial,
+		DialTLSContext:     blitz.dialTLS(tlsConfig),
+	}
+}
+
+// dialTLS returns a dial function that runs the TLS handshake with cfg
+// itself, so that it is counted in the TLS stats
+func (blitz *Blitz) dialTLS(cfg *tls.Config) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		conn, err := blitz.dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		host, _, _ := net.SplitHostPort(address)
+		return blitz.tlsHandshake(ctx, conn, tlsFor(cfg, host))
+	}
+}
+
+// closeIdle drops the idle connections of tr, so that the next request
+// dials again. http.Transport does it on its own when keep-alives are off.
+func closeIdle(tr http.RoundTripper) {
+	if closer, ok := tr.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// tlsHandshake runs the client handshake on conn, reporting it to the trace
+// of ctx the way http.Transport does, and to the TLS stats
+func (blitz *Blitz) tlsHandshake(ctx context.Context, conn net.Conn, cfg *tls.Config) (net.Conn, error) {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	start := time.Now()
+	tlsConn := tls.Client(conn, cfg)
+	err := tlsConn.HandshakeContext(ctx)
+	blitz.tls.record(tlsConn.ConnectionState(), time.Since(start), err)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
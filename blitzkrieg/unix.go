@@ -0,0 +1,55 @@
+package blitzkrieg
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// unixSockets maps the host names that stand in for Unix socket targets to
+// the paths of the sockets
+var unixSockets = make(map[string]string)
+
+// unixRequest rewrites the unix:///path/to/app.sock:/request/path URL of req
+// to an http URL on a host of its own, which the dialers connect to the
+// socket. The Host header defaults to localhost, as with curl --unix-socket.
+// Other URLs are left alone.
+func unixRequest(req *blitzRequest) {
+	if !strings.HasPrefix(req.url, "unix://") {
+		return
+	}
+	socket, path := strings.TrimPrefix(req.url, "unix://"), "/"
+	if i := strings.Index(socket, ":"); i >= 0 {
+		socket, path = socket[:i], socket[i+1:]
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	host := ""
+	for h, s := range unixSockets {
+		if s == socket {
+			host = h
+		}
+	}
+	if host == "" {
+		host = "unix" + strconv.Itoa(len(unixSockets)+1)
+		unixSockets[host] = socket
+	}
+	req.url = "http://" + host + path
+	if req.header.Get("Host") == "" {
+		req.header.Set("Host", "localhost")
+	}
+}
+
+// unixAddress returns where to dial address: the socket it stands for, if
+// it does
+func unixAddress(network string, address string) (string, string) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if socket, ok := unixSockets[host]; ok {
+		return "unix", socket
+	}
+	return network, address
+}
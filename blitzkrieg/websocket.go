@@ -0,0 +1,319 @@
+package blitzkrieg
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/gorilla/websocket"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// wsStats counts the WebSocket connections of the test
+type wsStats struct {
+	open     int64 // connections currently upgraded
+	maxOpen  int64
+	upgrades int64
+	drops    int64 // connections the server closed or reset
+}
+
+func (ws *wsStats) connected() {
+	atomic.AddInt64(&ws.upgrades, 1)
+	open := atomic.AddInt64(&ws.open, 1)
+	for {
+		max := atomic.LoadInt64(&ws.maxOpen)
+		if open <= max || atomic.CompareAndSwapInt64(&ws.maxOpen, max, open) {
+			return
+		}
+	}
+}
+
+func (ws *wsStats) dropped() {
+	atomic.AddInt64(&ws.open, -1)
+	atomic.AddInt64(&ws.drops, 1)
+}
+
+func (ws *wsStats) closed() {
+	atomic.AddInt64(&ws.open, -1)
+}
+
+// isDrop tells whether err, which broke a WebSocket connection, is the
+// server closing or resetting it
+func isDrop(err error) bool {
+	switch classifyError(err) {
+	case errWSClosed, errReset, errBrokenPipe, errEOF:
+		return true
+	}
+	return false
+}
+
+// wsReplies is the number of messages a connection keeps while no message
+// waits for its reply
+const wsReplies = 64
+
+// A wsConn is an upgraded connection of a wsClient. A goroutine of its own
+// reads it, so that waiting for a reply can time out and leave the
+// connection usable; replies that come too late are skipped by the next
+// message.
+type wsConn struct {
+	*websocket.Conn
+	replies chan []byte
+	done    chan struct{} // closed once reading fails
+	err     error         // the read error, set before done is closed
+}
+
+func newWSConn(conn *websocket.Conn) *wsConn {
+	wsc := &wsConn{Conn: conn, replies: make(chan []byte, wsReplies), done: make(chan struct{})}
+	go func() {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				wsc.err = err
+				close(wsc.done)
+				return
+			}
+			select {
+			case wsc.replies <- msg:
+			default:
+				// nobody waits for it, it would be skipped anyway
+			}
+		}
+	}()
+	return wsc
+}
+
+// isWebSocket tells whether rawurl is a ws:// or wss:// URL
+func isWebSocket(rawurl string) bool {
+	return strings.HasPrefix(rawurl, "ws://") || strings.HasPrefix(rawurl, "wss://")
+}
+
+// A wsClient is the WebSocket side of a simulated client. It keeps one
+// connection per URL, upgraded on the first message and again after a drop.
+// Every job is a message: it is sent, and the client waits for the reply
+// that matches the expect pattern, or for any reply when there is none.
+type wsClient struct {
+	blitz *Blitz
+	id    int64
+	conns map[string]*wsConn
+	seq   int64
+	next  time.Time // when the next message is due, with a message rate
+	tls   *tls.Config
+}
+
+func (blitz *Blitz) newWSClient() *wsClient {
+	return &wsClient{
+		blitz: blitz,
+		id:    atomic.AddInt64(&blitz.wsClients, 1),
+		conns: make(map[string]*wsConn),
+		tls:   clientTLS(),
+	}
+}
+
+// renderMessage fills the placeholders of a message template: {{client}} is
+// the number of the client, {{seq}} the number of the message on the client
+// and {{time}} the unix time in milliseconds
+func renderMessage(template string, client int64, seq int64) string {
+	return strings.NewReplacer(
+		"{{client}}", strconv.FormatInt(client, 10),
+		"{{seq}}", strconv.FormatInt(seq, 10),
+		"{{time}}", strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10),
+	).Replace(template)
+}
+
+// upgrade connects to req and reports the upgrade as a result of its own
+func (wc *wsClient) upgrade(req *blitzRequest) *wsConn {
+	blitz := wc.blitz
+	dialer := &websocket.Dialer{
+		NetDialContext:    blitz.dial,
+		NetDialTLSContext: blitz.dialTLS(wc.tls),
+		HandshakeTimeout:  time.Duration(blitz.connectTimeout+blitz.readTimeout) * time.Millisecond,
+		EnableCompression: blitz.gzip,
+	}
+	header := make(http.Header)
+	for key, values := range req.header {
+		switch key {
+		case "Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions", "Content-Length", "Content-Type":
+			continue
+		}
+		header[key] = values
+	}
+
+	timer := &phaseTimer{}
+	ctx := httptrace.WithClientTrace(context.Background(), timer.trace())
+	s := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, req.url, header)
+	timer.release()
+	end := time.Now()
+	result := &blitzResult{
+		name:      req.name + " (upgrade)",
+		duration:  end.Sub(s),
+		phases:    timer.finish(end),
+		newConn:   timer.gotConn,
+		success:   err == nil,
+		headerOut: int64(headerSize(header)),
+		timestamp: end,
+	}
+	if resp != nil {
+		result.statusCode, result.proto = resp.StatusCode, resp.Proto
+		result.headerIn = responseHeaderSize(resp)
+	}
+	// a handshake the server refused is an HTTP failure, not a transport error
+	if err != nil && !(errors.Is(err, websocket.ErrBadHandshake) && resp != nil) {
+		result.err, result.errClass = err, classifyError(err)
+	}
+	if blitz.samples != nil && err != nil {
+		blitz.samples.capture(req, s, end.Sub(s), resp, nil, result.err, result.errClass, nil)
+	}
+	blitz.results <- result
+	if err != nil {
+		return nil
+	}
+	blitz.ws.connected()
+	// replies are waited for with timers, the connection is read even while
+	// it is idle
+	if bConn := blitzConnOf(conn.UnderlyingConn()); bConn != nil {
+		bConn.readTimeout = 0
+	}
+	conn.SetReadDeadline(time.Time{})
+	return newWSConn(conn)
+}
+
+// close ends the connections of the client with a close frame, so that the
+// server does not take the end of the test for abnormal closures, and waits
+// for the server to close them in turn
+func (wc *wsClient) close() {
+	blitz := wc.blitz
+	for url, conn := range wc.conns {
+		msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		deadline := time.Now().Add(time.Duration(blitz.writeTimeout) * time.Millisecond)
+		if conn.WriteControl(websocket.CloseMessage, msg, deadline) == nil {
+			timeout := time.NewTimer(time.Duration(blitz.readTimeout) * time.Millisecond)
+			select {
+			case <-conn.done:
+			case <-timeout.C:
+			}
+			timeout.Stop()
+		}
+		conn.Close()
+		delete(wc.conns, url)
+		blitz.ws.closed()
+	}
+}
+
+// send sends the next message to req and waits for its reply
+func (wc *wsClient) send(req *blitzRequest) {
+	blitz := wc.blitz
+	conn := wc.conns[req.url]
+	if conn == nil {
+		if conn = wc.upgrade(req); conn == nil {
+			return
+		}
+		wc.conns[req.url] = conn
+	}
+	if blitz.msgRate > 0 {
+		if wait := time.Until(wc.next); wait > 0 {
+			time.Sleep(wait)
+		}
+		wc.next = time.Now().Add(time.Second / time.Duration(blitz.msgRate))
+	}
+
+	messages := blitz.messages
+	if req.body != "" {
+		messages = []string{req.body}
+	}
+	msg := renderMessage(messages[wc.seq%int64(len(messages))], wc.id, wc.seq)
+	var (
+		expect = blitz.expectRe
+		err    error
+	)
+	if expect == nil && blitz.expect != "" {
+		expect, err = regexp.Compile(renderMessage(blitz.expect, wc.id, wc.seq))
+	}
+	wc.seq++
+	for drained := false; !drained; {
+		select {
+		case <-conn.replies:
+		default:
+			drained = true
+		}
+	}
+
+	s := time.Now()
+	var (
+		reply      []byte
+		unanswered bool // whether the reply did not come in time
+	)
+	if err == nil {
+		conn.SetWriteDeadline(s.Add(time.Duration(blitz.writeTimeout) * time.Millisecond))
+		err = conn.WriteMessage(websocket.TextMessage, []byte(msg))
+	}
+	if err == nil {
+		timeout := time.NewTimer(time.Duration(blitz.readTimeout) * time.Millisecond)
+	wait:
+		for {
+			select {
+			case reply = <-conn.replies:
+				if expect == nil || expect.Match(reply) {
+					break wait
+				}
+			case <-conn.done:
+				err = conn.err
+				break wait
+			case <-timeout.C:
+				// as a read deadline would, so that it counts as a read timeout
+				err = &net.OpError{Op: "read", Net: "tcp", Addr: conn.RemoteAddr(), Err: os.ErrDeadlineExceeded}
+				unanswered = true
+				break wait
+			}
+		}
+		timeout.Stop()
+	}
+	if err != nil {
+		reply = nil
+	}
+	end := time.Now()
+	var failed []string
+	if err == nil {
+		failed = req.verify(nil, reply, end.Sub(s))
+	}
+	result := &blitzResult{
+		name:         req.name,
+		duration:     end.Sub(s),
+		proto:        "websocket",
+		success:      err == nil && len(failed) == 0,
+		failedChecks: failed,
+		bodySize:     int64(len(reply)),
+		timestamp:    end,
+	}
+	if len(failed) > 0 && blitz.samples != nil {
+		blitz.samples.capture(req, s, end.Sub(s), nil, reply, nil, "websocket", failed)
+	}
+	switch {
+	case err == nil:
+		if result.success {
+			result.contentLength = int64(len(reply))
+		}
+	case unanswered || expect == nil && blitz.expect != "":
+		// the connection is left as it is when the reply is late, or when
+		// the pattern rendered for it does not compile
+		result.err, result.errClass = err, classifyError(err)
+	default:
+		// the connection is of no use after a failed read or write
+		result.err, result.errClass = err, classifyError(err)
+		conn.Close()
+		delete(wc.conns, req.url)
+		if isDrop(err) {
+			blitz.ws.dropped()
+		} else {
+			blitz.ws.closed()
+		}
+	}
+	blitz.results <- result
+}